@@ -1,78 +1,215 @@
 package gmadapter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/rs/zerolog/log"
-	"gopkg.in/yaml.v3"
+	"github.com/rs/zerolog"
 )
 
 // OpenAPIToMCPAdapter 是一个适配器，用于将 OpenAPI 文档中的server转换为 MCP 工具
 type OpenAPIToMCPAdapter struct {
 	server         *server.MCPServer
+	name           string
 	backendBaseUrl string
 	addrs          string
 	openAPI        map[string]interface{}
 	tools          map[string]*mcp.Tool
 	handlers       map[string]func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+	toolsMu        sync.RWMutex
+	updateMu       sync.Mutex
+	customTools    map[string]bool
+	disabled       map[string]bool
+
+	hooks               *server.Hooks
+	identityHeaders     []string
+	identityTransformer IdentityTransformer
+	identities          sync.Map
+	authProvider        AuthProvider
+	httpClient          *http.Client
+	httpClientConfig    *HTTPClientConfig
+	defaultTimeout      time.Duration
+	operationTimeouts   map[string]time.Duration
+	callIDs             sync.Map
+	inflightCalls       sync.Map
+	retryPolicy         *RetryPolicy
+	operationRetries    map[string]*RetryPolicy
+	breakerConfig       *BreakerConfig
+	breakersMu          sync.Mutex
+	breakers            map[string]*circuitBreaker
+	metrics             *metricsRegistry
+	rateLimitConfig     *RateLimitConfig
+	rateLimiter         *rateLimiter
+	workerPool          *WorkerPool
+	backendEndpoints    []Endpoint
+	balancerConfig      LoadBalancerConfig
+	backends            *endpointSet
+	discoverer          Discoverer
+	cache               *responseCache
+	coalescing          *coalesceConfig
+	transports          []string
+	stdin               io.Reader
+	stdout              io.Writer
+	websocketConfig     WebSocketConfig
+	serverOptions       []server.ServerOption
+	sseOptions          []server.SSEOption
+	contextFunc         server.SSEContextFunc
+	logger              *zerolog.Logger
+	naming              NamingFunc
+	filter              FilterFunc
+	basePath            string
+	registerOnce        sync.Once
+	handlerOnce         sync.Once
+	handler             http.Handler
+	httpShutdown        []func(context.Context) error
+	attachMu            sync.Mutex
+	attached            map[*server.MCPServer]attachment
+	sessions            sync.Map
+	draining            atomic.Bool
+	gracePeriod         time.Duration
+	callsMu             sync.Mutex
+	calls               map[*inflightCall]struct{}
+	callsIdle           chan struct{}
+	stopMu              sync.Mutex
+	stopServing         context.CancelFunc
+	shutdownOnce        sync.Once
+	shutdownSummary     ShutdownSummary
+	shutdownErr         error
+	membersMu           sync.Mutex
+	members             []*OpenAPIToMCPAdapter
+	mergedHeaders       []string
+	mounts              []*OpenAPIToMCPAdapter
+	memberPrefixes      map[*OpenAPIToMCPAdapter]string
+	mergedTools         map[string]*OpenAPIToMCPAdapter
+	hosts               []*OpenAPIToMCPAdapter
+	reloadConfig        *ReloadConfig
+	reloadMu            sync.Mutex
+	spec                specVersion
+	specFetchConfig     *SpecFetchConfig
 }
 
-// NewOpenAPIToMCPAdapter 创建一个新的适配器
-func NewOpenAPIToMCPAdapter(name, version, backendBaseUrl, myAddr string) (*OpenAPIToMCPAdapter, error) {
-	s := server.NewMCPServer(name, version)
+// Option 用于配置适配器
+type Option func(*OpenAPIToMCPAdapter)
 
-	return &OpenAPIToMCPAdapter{
-		server:         s,
+// NewOpenAPIToMCPAdapter 创建一个新的适配器
+func NewOpenAPIToMCPAdapter(name, version, backendBaseUrl, myAddr string, opts ...Option) (*OpenAPIToMCPAdapter, error) {
+	a := &OpenAPIToMCPAdapter{
+		name:           name,
 		backendBaseUrl: backendBaseUrl,
 		addrs:          myAddr,
 		tools:          make(map[string]*mcp.Tool),
 		handlers:       make(map[string]func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)),
-	}, nil
-}
-
-// LoadOpenAPI 从 URL 或本地文件加载 OpenAPI 文档
-func (a *OpenAPIToMCPAdapter) LoadOpenAPI(source string) error {
-	var data []byte
-	var err error
+		hooks:          &server.Hooks{},
+		metrics:        newMetricsRegistry(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
 
-	if strings.HasPrefix(source, "http") {
-		resp, err := http.Get(source)
+	if a.httpClient == nil && a.httpClientConfig != nil {
+		client, err := NewHTTPClient(*a.httpClientConfig)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer resp.Body.Close()
-		data, err = ioutil.ReadAll(resp.Body)
+		a.httpClient = client
+	}
+	if a.rateLimitConfig != nil {
+		limiter, err := newRateLimiter(*a.rateLimitConfig)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	} else {
-		data, err = ioutil.ReadFile(source)
+		a.rateLimiter = limiter
+	}
+	if cfg := a.specFetchConfig; cfg != nil && cfg.Client == nil && cfg.HTTPClient != nil {
+		client, err := NewHTTPClient(*cfg.HTTPClient)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		cfg.Client = client
 	}
 
-	if err := yaml.Unmarshal(data, &a.openAPI); err != nil {
-		return err
+	if len(a.backendEndpoints) > 0 || a.balancerConfig != (LoadBalancerConfig{}) || a.discoverer != nil {
+		if len(a.backendEndpoints) == 0 && backendBaseUrl != "" {
+			a.backendEndpoints = []Endpoint{{URL: backendBaseUrl}}
+		}
+		if a.backendBaseUrl == "" && len(a.backendEndpoints) > 0 {
+			a.backendBaseUrl = a.backendEndpoints[0].URL
+		}
+		a.backends = newEndpointSet(a.balancerConfig, a.backendEndpoints)
+		a.backends.logger = a.log()
+		a.backends.blocked = a.endpointBlocked
 	}
 
-	return nil
+	a.metrics.gauge(a.breakerGauge)
+	if a.workerPool != nil {
+		a.metrics.gauge(a.workerPoolGauge)
+	}
+	if a.cache != nil {
+		a.metrics.gauge(a.cacheGauge)
+	}
+	a.hooks.AddOnRegisterSession(a.onRegisterSession)
+	a.hooks.AddBeforeCallTool(a.beforeCallTool)
+	a.hooks.AddAfterCallTool(a.afterCallTool)
+	a.hooks.AddOnError(a.onCallToolError)
+	// 热加载和运行时注册会增删工具，需要告诉客户端工具列表可能变化
+	serverOpts := []server.ServerOption{server.WithToolCapabilities(true)}
+	serverOpts = append(append(serverOpts, a.serverOptions...), server.WithHooks(a.hooks))
+	a.server = server.NewMCPServer(name, version, serverOpts...)
+	a.server.AddNotificationHandler("notifications/cancelled", a.handleCancelled)
+
+	return a, nil
+}
+
+// LoadOpenAPI 从 http(s) URL 或本地文件加载 OpenAPI 文档，本地文件也可以写成 file:// URL，
+// JSON 和 YAML 格式均可。WithSpecReload 会监听这个来源。
+func (a *OpenAPIToMCPAdapter) LoadOpenAPI(source string) error {
+	location, err := specLocation(source)
+	if err != nil {
+		return err
+	}
+	data, version, err := a.fetchSpec(context.Background(), location)
+	if err != nil {
+		return err
+	}
+	return a.loadSpec(data, version)
 }
 
 // GenerateTools 从 OpenAPI 文档生成 MCP 工具
 func (a *OpenAPIToMCPAdapter) GenerateTools() error {
-	paths, ok := a.openAPI["paths"].(map[string]interface{})
+	tools, handlers, err := a.buildTools(a.openAPI)
+	if err != nil {
+		return err
+	}
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+	for toolName, tool := range tools {
+		a.tools[toolName] = tool
+		a.handlers[toolName] = handlers[toolName]
+	}
+	return nil
+}
+
+// buildTools 从 OpenAPI 文档生成工具及其处理函数，不修改已有的工具
+func (a *OpenAPIToMCPAdapter) buildTools(openAPI map[string]interface{}) (map[string]*mcp.Tool, map[string]server.ToolHandlerFunc, error) {
+	paths, ok := openAPI["paths"].(map[string]interface{})
 	if !ok {
-		return errors.New("failed to extract paths from OpenAPI document")
+		return nil, nil, errors.New("failed to extract paths from OpenAPI document")
 	}
+	tools := make(map[string]*mcp.Tool)
+	handlers := make(map[string]server.ToolHandlerFunc)
 
 	for path, pathItem := range paths {
 		pathItemMap, ok := pathItem.(map[string]interface{})
@@ -86,125 +223,199 @@ func (a *OpenAPIToMCPAdapter) GenerateTools() error {
 				continue
 			}
 
-			toolName := fmt.Sprintf("%s_%s", strings.ReplaceAll(path, "/", "_"), method)
-			toolDesc := ""
-			if summary, ok := operationMap["summary"].(string); ok {
-				toolDesc = summary
+			if a.filter != nil && !a.filter(method, path, operationMap) {
+				continue
 			}
-			if toolDesc == "" {
-				if desc, ok := operationMap["description"].(string); ok {
-					toolDesc = desc
-				}
+			tool, handler, err := a.buildTool(method, path, operationMap)
+			if err != nil {
+				return nil, nil, fmt.Errorf("generate tool for %s %s: %w", strings.ToUpper(method), path, err)
 			}
+			if tool == nil {
+				continue
+			}
+			tools[tool.Name] = tool
+			handlers[tool.Name] = handler
+		}
+	}
 
-			var toolOpts []mcp.ToolOption
-			toolOpts = append(toolOpts, mcp.WithDescription(toolDesc))
+	return tools, handlers, nil
+}
 
-			// 处理路径参数和查询参数
-			if params, ok := operationMap["parameters"].([]interface{}); ok {
-				for _, param := range params {
-					paramMap, ok := param.(map[string]interface{})
-					if !ok {
-						continue
-					}
+// toolName 按 WithNaming 设置的规则返回操作的工具名
+func (a *OpenAPIToMCPAdapter) toolName(method, path string, operationMap map[string]interface{}) string {
+	if a.naming == nil {
+		return defaultToolName(method, path, operationMap)
+	}
+	return a.naming(method, path, operationMap)
+}
+
+// toolMethods 返回文档中每个操作的工具名对应的 HTTP 方法
+func (a *OpenAPIToMCPAdapter) toolMethods(openAPI map[string]interface{}) map[string]string {
+	methods := make(map[string]string)
+	paths, _ := openAPI["paths"].(map[string]interface{})
+	for path, pathItem := range paths {
+		pathItemMap, ok := pathItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for method, operation := range pathItemMap {
+			if operationMap, ok := operation.(map[string]interface{}); ok {
+				methods[a.toolName(method, path, operationMap)] = method
+			}
+		}
+	}
+	return methods
+}
 
-					paramName, _ := paramMap["name"].(string)
-					paramDesc, _ := paramMap["description"].(string)
-					required, _ := paramMap["required"].(bool)
+// buildTool 从一个 OpenAPI 操作生成工具及其处理函数，请求体格式无法识别时返回 nil，参数或请求体的 schema 格式错误时返回错误
+func (a *OpenAPIToMCPAdapter) buildTool(method, path string, operationMap map[string]interface{}) (*mcp.Tool, server.ToolHandlerFunc, error) {
+	toolName := a.toolName(method, path, operationMap)
+	toolDesc := ""
+	if summary, ok := operationMap["summary"].(string); ok {
+		toolDesc = summary
+	}
+	if toolDesc == "" {
+		if desc, ok := operationMap["description"].(string); ok {
+			toolDesc = desc
+		}
+	}
 
-					schemaMap := paramMap["schema"].(map[string]interface{})
-					generator := map[string]interface{}{
-						"type":        schemaMap["type"],
-						"description": paramDesc,
-						"required":    required,
-					}
-					paramDef, ok := paramMap["default"]
-					if ok {
-						generator["default"] = paramDef
-					}
-					paramProps, ok := schemaMap["properties"]
-					if ok {
-						generator["props"] = paramProps.(map[string]interface{})
-					}
-					paramItems, ok := schemaMap["items"]
-					if ok {
-						generator["items"] = paramItems
-					}
-					opt, err := a.getMCPPropertyOption(paramName, generator)
-					if err != nil {
-						log.Printf("failed to create property option for %s: %v", paramName, err)
-						continue
-					}
-					toolOpts = append(toolOpts, opt)
+	var toolOpts []mcp.ToolOption
+	toolOpts = append(toolOpts, mcp.WithDescription(toolDesc))
+
+	// 处理路径参数和查询参数
+	if params, ok := operationMap["parameters"].([]interface{}); ok {
+		for _, param := range params {
+			paramMap, ok := param.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			paramName, _ := paramMap["name"].(string)
+			paramDesc, _ := paramMap["description"].(string)
+			required, _ := paramMap["required"].(bool)
+
+			schema, ok := paramMap["schema"]
+			if !ok {
+				a.log().Printf("parameter %s of %s has no schema, skipping it", paramName, toolName)
+				continue
+			}
+			schemaMap, ok := schema.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("schema of parameter %s is not an object", paramName)
+			}
+			generator := map[string]interface{}{
+				"type":        schemaMap["type"],
+				"description": paramDesc,
+				"required":    required,
+			}
+			paramDef, ok := paramMap["default"]
+			if ok {
+				generator["default"] = paramDef
+			}
+			paramProps, ok := schemaMap["properties"]
+			if ok {
+				props, ok := paramProps.(map[string]interface{})
+				if !ok {
+					return nil, nil, fmt.Errorf("properties of parameter %s is not an object", paramName)
 				}
+				generator["props"] = props
+			}
+			paramItems, ok := schemaMap["items"]
+			if ok {
+				generator["items"] = paramItems
+			}
+			opt, err := a.getMCPPropertyOption(paramName, generator)
+			if err != nil {
+				a.log().Printf("failed to create property option for %s: %v", paramName, err)
+				continue
 			}
+			toolOpts = append(toolOpts, opt)
+		}
+	}
+
+	// 处理请求体
+	if requestBody, ok := operationMap["requestBody"].(map[string]interface{}); ok {
+		content, ok := requestBody["content"].(map[string]interface{})
+		if !ok {
+			return nil, nil, nil
+		}
 
-			// 处理请求体
-			if requestBody, ok := operationMap["requestBody"].(map[string]interface{}); ok {
-				content, ok := requestBody["content"].(map[string]interface{})
+		for mediaType, schema := range content {
+			schemaMap, ok := schema.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			bodySchema, ok := schemaMap["schema"]
+			if !ok {
+				continue
+			}
+			bodySchemaMap, ok := bodySchema.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("request body schema for %s is not an object", mediaType)
+			}
+			properties, ok := bodySchemaMap["properties"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for paramName, param := range properties {
+				paramMap, ok := param.(map[string]interface{})
 				if !ok {
 					continue
 				}
 
-				for _, schema := range content {
-					schemaMap, ok := schema.(map[string]interface{})
-					if !ok {
-						continue
-					}
+				paramType, ok := paramMap["type"].(string)
+				if !ok {
+					continue
+				}
+				paramDesc, _ := paramMap["description"].(string)
 
-					properties, ok := schemaMap["schema"].(map[string]interface{})["properties"].(map[string]interface{})
+				generator := map[string]interface{}{
+					"type":        paramType,
+					"description": paramDesc,
+				}
+				paramDef, ok := paramMap["default"]
+				if ok {
+					generator["default"] = paramDef
+				}
+				paramProps, ok := paramMap["properties"]
+				if ok {
+					props, ok := paramProps.(map[string]interface{})
 					if !ok {
-						continue
+						return nil, nil, fmt.Errorf("properties of request body field %s is not an object", paramName)
 					}
+					generator["props"] = props
+				}
+				paramItems, ok := paramMap["items"]
+				if ok {
+					generator["items"] = paramItems
+				}
 
-					for paramName, param := range properties {
-						paramMap, ok := param.(map[string]interface{})
-						if !ok {
-							continue
-						}
-
-						paramType, ok := paramMap["type"].(string)
-						if !ok {
-							continue
-						}
-						paramDesc, _ := paramMap["description"].(string)
-
-						generator := map[string]interface{}{
-							"type":        paramType,
-							"description": paramDesc,
-						}
-						paramDef, ok := paramMap["default"]
-						if ok {
-							generator["default"] = paramDef
-						}
-						paramProps, ok := paramMap["properties"]
-						if ok {
-							generator["props"] = paramProps.(map[string]interface{})
-						}
-						paramItems, ok := paramMap["items"]
-						if ok {
-							generator["items"] = paramItems
-						}
-
-						opt, err := a.getMCPPropertyOption(paramName, generator)
-						if err != nil {
-							log.Printf("failed to create property option for %s: %v", paramName, err)
-							continue
-						}
-						toolOpts = append(toolOpts, opt)
-					}
+				opt, err := a.getMCPPropertyOption(paramName, generator)
+				if err != nil {
+					a.log().Printf("failed to create property option for %s: %v", paramName, err)
+					continue
 				}
+				toolOpts = append(toolOpts, opt)
 			}
-
-			tool := mcp.NewTool(toolName, toolOpts...)
-			a.tools[toolName] = &tool
-			a.handlers[toolName] = a.createHandler(path, method)
-
-			log.Printf("create a tool for %s", toolName)
 		}
 	}
 
-	return nil
+	op := toolOperation{
+		toolName: toolName,
+		path:     path,
+		method:   method,
+		timeout:  a.operationTimeout(toolName, operationMap),
+		retry:    a.operationRetryPolicy(toolName),
+		cacheTTL: a.operationCacheTTL(toolName, operationMap),
+		coalesce: a.operationCoalesce(toolName, method, operationMap),
+	}
+
+	tool := mcp.NewTool(toolName, toolOpts...)
+	a.log().Printf("create a tool for %s", toolName)
+	return &tool, a.createHandler(op), nil
 }
 
 // getMCPPropertyOption 根据参数类型返回对应的 MCP 属性选项
@@ -244,7 +455,7 @@ func (a *OpenAPIToMCPAdapter) getMCPPropertyOption(name string, param map[string
 		case []any:
 			propOpts = append(propOpts, mcp.DefaultArray(do))
 		default:
-			propOpts = append(propOpts, mcp.DefaultString(do.(string)))
+			return nil, fmt.Errorf("unsupported default value %v", def)
 		}
 	}
 
@@ -282,12 +493,43 @@ func (a *OpenAPIToMCPAdapter) getMCPPropertyOption(name string, param map[string
 	}
 }
 
+// toolOperation 描述工具对应的 OpenAPI 操作
+type toolOperation struct {
+	toolName string
+	path     string
+	method   string
+	timeout  time.Duration
+	retry    *RetryPolicy
+	cacheTTL time.Duration
+	coalesce bool
+}
+
+// backendResponse 是一次后端调用的结果
+type backendResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	attempts   int
+}
+
 // createHandler 为工具生成处理函数
-func (a *OpenAPIToMCPAdapter) createHandler(path, method string) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (a *OpenAPIToMCPAdapter) createHandler(op toolOperation) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, method := op.path, op.method
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := a.trackCall(ctx, op.toolName)
+		defer cancel()
+		if a.draining.Load() {
+			return mcp.NewToolResultError("the MCP adapter is shutting down; try again later"), nil
+		}
+		if op.timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeout(ctx, op.timeout)
+			defer cancelTimeout()
+		}
+
 		args := request.Params.Arguments
 
-		url := a.backendBaseUrl + path
+		url := path
 		for key, value := range args {
 			if strings.Contains(url, "{"+key+"}") {
 				url = strings.Replace(url, "{"+key+"}", value.(string), 1)
@@ -300,42 +542,196 @@ func (a *OpenAPIToMCPAdapter) createHandler(path, method string) func(ctx contex
 			body, _ = json.Marshal(args)
 		}
 
-		// 发送请求
-		client := &http.Client{}
-		req, err := http.NewRequest(strings.ToUpper(method), url, strings.NewReader(string(body)))
-		if err != nil {
-			return nil, err
+		// 新鲜的缓存直接返回，过期但可重新验证的缓存交给 invoke 发起条件请求
+		var cacheKey string
+		var cached *cacheEntry
+		if a.cacheable(op) {
+			cacheKey = a.cache.key(ctx, method, url)
+			entry, fresh := a.cache.get(cacheKey)
+			if fresh {
+				a.metrics.add("mcp_adapter_cache_requests_total", 1, "tool", op.toolName, "result", "hit")
+				return cachedResult(entry, "hit"), nil
+			}
+			cached = entry
 		}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, err
+		invoke := func(ctx context.Context) (*mcp.CallToolResult, error) {
+			return a.invoke(ctx, op, url, body, cacheKey, cached)
+		}
+		if !op.coalesce {
+			return invoke(ctx)
 		}
-		defer resp.Body.Close()
+		return a.coalesce(ctx, op, url, body, invoke)
+	}
+}
 
-		// 读取响应
-		respBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
+// invoke 经过限流、断路器和工作池调用后端，并把结果写入缓存
+func (a *OpenAPIToMCPAdapter) invoke(ctx context.Context, op toolOperation, url string, body []byte, cacheKey string, cached *cacheEntry) (*mcp.CallToolResult, error) {
+	var conditional http.Header
+	if cached != nil {
+		conditional = http.Header{}
+		if cached.etag != "" {
+			conditional.Set("If-None-Match", cached.etag)
 		}
+		if cached.lastModified != "" {
+			conditional.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
 
-		return mcp.NewToolResultText(string(respBody)), nil
+	if limited, err := a.checkRateLimit(ctx, op.toolName); limited != nil || err != nil {
+		return limited, err
 	}
+
+	breakers, reason := acquireBreakers(a.operationBreakers(op))
+	if reason != "" {
+		a.metrics.add("mcp_adapter_circuit_breaker_rejections_total", 1, "tool", op.toolName)
+		return mcp.NewToolResultError(reason), nil
+	}
+
+	release, busy, err := a.acquireWorker(ctx, op)
+	if busy != nil || err != nil {
+		for _, b := range breakers {
+			b.abort()
+		}
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return timeoutResult(op), nil
+		}
+		return busy, err
+	}
+	defer release()
+
+	// 发送请求
+	start := time.Now()
+	resp, err := a.callBackend(ctx, op, url, body, conditional)
+	var open *breakerOpenError
+	if errors.As(err, &open) {
+		for _, b := range breakers {
+			b.abort()
+		}
+		a.metrics.add("mcp_adapter_circuit_breaker_rejections_total", 1, "tool", op.toolName)
+		return mcp.NewToolResultError(open.reason), nil
+	}
+	a.recordBackendCall(ctx, op, breakers, resp, err, time.Since(start))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return timeoutResult(op), nil
+		}
+		return nil, err
+	}
+
+	if cacheKey != "" {
+		a.storeResponse(op, cacheKey, cached, resp)
+		if cached != nil && resp.statusCode == http.StatusNotModified {
+			a.metrics.add("mcp_adapter_cache_requests_total", 1, "tool", op.toolName, "result", "revalidated")
+			return cachedResult(cached, "revalidated"), nil
+		}
+		a.metrics.add("mcp_adapter_cache_requests_total", 1, "tool", op.toolName, "result", "miss")
+	}
+
+	result := mcp.NewToolResultText(string(resp.body))
+	if op.retry != nil && op.retry.MaxAttempts > 1 {
+		result.Meta = map[string]interface{}{"attempts": resp.attempts}
+	}
+	return result, nil
 }
 
-// Start 启动 MCP 服务器
-func (a *OpenAPIToMCPAdapter) Start(ctx context.Context) error {
-	for toolName, tool := range a.tools {
-		handler := a.handlers[toolName]
-		a.server.AddTool(*tool, handler)
+// timeoutResult 返回工具调用超时的错误结果
+func timeoutResult(op toolOperation) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("backend call %s timed out after %s", op.toolName, op.timeout))
+}
+
+// recordBackendCall 记录后端调用的指标和断路器结果
+func (a *OpenAPIToMCPAdapter) recordBackendCall(ctx context.Context, op toolOperation, breakers []*circuitBreaker, resp *backendResponse, err error, elapsed time.Duration) {
+	status := "error"
+	if resp != nil {
+		status = strconv.Itoa(resp.statusCode)
+	}
+	a.metrics.add("mcp_adapter_backend_requests_total", 1, "tool", op.toolName, "status", status)
+	a.metrics.observe("mcp_adapter_backend_request_duration", elapsed, "tool", op.toolName)
+
+	// 客户端主动取消的调用不代表后端故障
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		for _, b := range breakers {
+			b.abort()
+		}
+		return
+	}
+	releaseBreakers(breakers, err == nil && resp.statusCode < http.StatusInternalServerError)
+}
+
+// newBackendRequest 构建后端请求并附加会话身份和认证信息
+func (a *OpenAPIToMCPAdapter) newBackendRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := a.applyIdentity(ctx, req); err != nil {
+		return nil, err
 	}
+	if a.authProvider != nil {
+		if err := a.authProvider.Authenticate(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// doBackend 发送一次后端请求并读取完整响应
+func (a *OpenAPIToMCPAdapter) doBackend(req *http.Request) (*backendResponse, error) {
+	resp, err := a.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backendResponse{
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       respBody,
+	}, nil
+}
 
-	log.Info().Msgf("start mcp adapter at %s", a.addrs)
-	s := server.NewSSEServer(a.server)
-	go func() {
-		<-ctx.Done()
-		s.Shutdown(ctx)
-	}()
+// registerTools 把生成的工具注册到 MCP 服务器，只执行一次
+func (a *OpenAPIToMCPAdapter) registerTools() {
+	a.registerOnce.Do(func() {
+		a.toolsMu.Lock()
+		defer a.toolsMu.Unlock()
+		for _, toolName := range a.enabledTools() {
+			a.server.AddTool(*a.tools[toolName], a.toolHandler(toolName))
+		}
+	})
+}
 
-	return s.Start(a.addrs)
+// StartBackground 在后台启动后端健康检查和服务发现，直到 ctx 结束，合并和挂载的适配器一并启动。
+// Start 会自动调用；通过 Handler 嵌入到自己的 HTTP 服务时需要手动调用。
+func (a *OpenAPIToMCPAdapter) StartBackground(ctx context.Context) {
+	for _, child := range a.children() {
+		child.StartBackground(ctx)
+	}
+	if a.reloadConfig != nil {
+		go a.watchSpec(ctx)
+	}
+	if a.backends == nil {
+		return
+	}
+	go a.backends.runHealthChecks(ctx, a.client())
+	if a.discoverer != nil {
+		go a.discoverer.Watch(a.log().WithContext(ctx), a.backends.update)
+	}
+}
+
+// Start 启动 MCP 服务器，按 WithTransports 选择的传输方式提供服务，直到 ctx 结束
+func (a *OpenAPIToMCPAdapter) Start(ctx context.Context) error {
+	if _, err := a.enabledTransports(); err != nil {
+		return err
+	}
+	a.registerTools()
+	a.StartBackground(ctx)
+	return a.serveTransports(ctx)
 }
@@ -868,3 +868,52 @@ func TestGenerateTools_RequestBody_StringWithDefault(t *testing.T) {
 	required := tool.InputSchema.Required
 	assert.Equal(t, 0, len(required))
 }
+
+func TestGenerateTools_MalformedSchema(t *testing.T) {
+	newAdapter := func(operation map[string]interface{}) *OpenAPIToMCPAdapter {
+		return &OpenAPIToMCPAdapter{
+			backendBaseUrl: "http://localhost:8080",
+			addrs:          "localhost:8080",
+			openAPI: map[string]interface{}{
+				"paths": map[string]interface{}{
+					"/test": map[string]interface{}{"post": operation},
+				},
+			},
+			tools:    make(map[string]*mcp.Tool),
+			handlers: make(map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)),
+		}
+	}
+
+	// 参数的 schema 不是对象
+	adapter := newAdapter(map[string]interface{}{
+		"parameters": []interface{}{
+			map[string]interface{}{"name": "id", "schema": "string"},
+		},
+	})
+	assert.ErrorContains(t, adapter.GenerateTools(), "generate tool for POST /test: schema of parameter id is not an object")
+
+	// 请求体的 schema 不是对象
+	adapter = newAdapter(map[string]interface{}{
+		"requestBody": map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": []interface{}{}},
+			},
+		},
+	})
+	assert.ErrorContains(t, adapter.GenerateTools(), "request body schema for application/json is not an object")
+
+	// 没有 schema 的参数和无法识别的默认值被跳过，工具照常生成
+	adapter = newAdapter(map[string]interface{}{
+		"parameters": []interface{}{
+			map[string]interface{}{"name": "id", "content": map[string]interface{}{}},
+			map[string]interface{}{"name": "filter", "schema": map[string]interface{}{"type": "string"}, "default": map[string]interface{}{}},
+			map[string]interface{}{"name": "q", "schema": map[string]interface{}{"type": "string"}},
+		},
+	})
+	assert.NoError(t, adapter.GenerateTools())
+	tool := adapter.tools["_test_post"]
+	if assert.NotNil(t, tool) {
+		assert.Len(t, tool.InputSchema.Properties, 1)
+		assert.Contains(t, tool.InputSchema.Properties, "q")
+	}
+}
@@ -0,0 +1,66 @@
+package gmadapter
+
+import (
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AttachTo 把生成的工具注册到调用方已有的 MCP 服务器上，prefix 非空时加在工具名前面，返回注册的工具名。
+// 对同一个服务器重复调用会先移除上一次注册的工具；开启 WithSpecReload 时文档的改动会同步到该服务器。
+// 调用方服务器上的身份透传需要把 ContextFunc 传给 server.WithSSEContextFunc。
+func (a *OpenAPIToMCPAdapter) AttachTo(s *server.MCPServer, prefix string) []string {
+	// 整个替换过程持有 attachMu，并发的 AttachTo、DetachFrom 和热加载同步不会看到中间状态
+	a.attachMu.Lock()
+	defer a.attachMu.Unlock()
+	a.toolsMu.RLock()
+	names := a.enabledTools()
+
+	tools := make([]server.ServerTool, 0, len(names))
+	current := make(map[string]bool, len(names))
+	for i, toolName := range names {
+		tool := *a.tools[toolName]
+		tool.Name = prefix + toolName
+		names[i] = tool.Name
+		current[tool.Name] = true
+		tools = append(tools, server.ServerTool{Tool: tool, Handler: a.toolHandler(toolName)})
+	}
+	a.toolsMu.RUnlock()
+
+	// 只移除这次不再注册的工具，同名的工具由 AddTools 直接替换
+	var stale []string
+	for _, name := range a.attached[s].names {
+		if !current[name] {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		s.DeleteTools(stale...)
+	}
+	s.AddTools(tools...)
+
+	if a.attached == nil {
+		a.attached = make(map[*server.MCPServer]attachment)
+	}
+	a.attached[s] = attachment{prefix: prefix, names: names}
+	return names
+}
+
+// DetachFrom 从服务器上移除 AttachTo 注册的工具，不影响服务器上的其他工具
+func (a *OpenAPIToMCPAdapter) DetachFrom(s *server.MCPServer) {
+	a.attachMu.Lock()
+	defer a.attachMu.Unlock()
+	if att, ok := a.attached[s]; ok && len(att.names) > 0 {
+		s.DeleteTools(att.names...)
+	}
+	delete(a.attached, s)
+}
+
+// attachment 记录 AttachTo 在一个服务器上注册的工具
+type attachment struct {
+	prefix string
+	names  []string
+}
+
+// ContextFunc 返回为每条消息合并身份头的上下文函数，把工具挂载到其他服务器时使用
+func (a *OpenAPIToMCPAdapter) ContextFunc() server.SSEContextFunc {
+	return a.sseContextFunc
+}
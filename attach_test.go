@@ -0,0 +1,79 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func listToolNames(t *testing.T, s *server.MCPServer) []string {
+	data, err := json.Marshal(s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+	assert.NoError(t, err)
+	var response struct {
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &response))
+	var names []string
+	for _, tool := range response.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestAttachTo_ExistingServer(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("from backend"))
+	}))
+	defer backend.Close()
+
+	s := server.NewMCPServer("mine", "1.0.0")
+	s.AddTool(mcp.NewTool("hello"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("hi"), nil
+	})
+
+	adapter := newIdentityTestAdapter(t, backend.URL)
+	assert.Equal(t, []string{"petstore_whoami_get"}, adapter.AttachTo(s, "petstore"))
+	assert.ElementsMatch(t, []string{"hello", "petstore_whoami_get"}, listToolNames(t, s))
+
+	data, _ := json.Marshal(s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"petstore_whoami_get","arguments":{}}}`)))
+	assert.Contains(t, string(data), "from backend")
+
+	// 重新挂载时替换上一次的工具
+	assert.Equal(t, []string{"v2_whoami_get"}, adapter.AttachTo(s, "v2"))
+	assert.ElementsMatch(t, []string{"hello", "v2_whoami_get"}, listToolNames(t, s))
+
+	adapter.DetachFrom(s)
+	assert.Equal(t, []string{"hello"}, listToolNames(t, s))
+	adapter.DetachFrom(s)
+}
+
+func TestAttachTo_Concurrent(t *testing.T) {
+	s := server.NewMCPServer("mine", "1.0.0")
+	adapter := newIdentityTestAdapter(t, "http://backend")
+
+	// 并发的重新挂载和移除不会留下其他前缀的工具
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				adapter.AttachTo(s, fmt.Sprintf("p%d", i))
+				if j%3 == 0 {
+					adapter.DetachFrom(s)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	adapter.AttachTo(s, "final")
+	assert.Equal(t, []string{"final_whoami_get"}, listToolNames(t, s))
+}
@@ -0,0 +1,332 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AuthProvider 为发往后端的请求附加认证信息
+type AuthProvider interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// WithAuthProvider 设置后端调用使用的认证提供者
+func WithAuthProvider(p AuthProvider) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.authProvider = p
+	}
+}
+
+// StaticAuthProvider 为每个后端请求附加固定的请求头和查询参数，例如 API Key 或静态 Bearer 令牌
+type StaticAuthProvider struct {
+	Header http.Header
+	Query  url.Values
+}
+
+// Authenticate 实现 AuthProvider 接口
+func (p *StaticAuthProvider) Authenticate(ctx context.Context, req *http.Request) error {
+	for name, values := range p.Header {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if len(p.Query) > 0 {
+		query := req.URL.Query()
+		for name, values := range p.Query {
+			query[name] = append([]string(nil), values...)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	return nil
+}
+
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+	// tokenExchangeTimeout 限制一次共享交换的时长，交换不随发起它的调用取消
+	tokenExchangeTimeout = 30 * time.Second
+)
+
+// TokenExchangeConfig 是 RFC 8693 令牌交换的配置
+type TokenExchangeConfig struct {
+	// TokenURL 令牌交换端点
+	TokenURL string `json:"token_url,omitempty" yaml:"token_url,omitempty"`
+	// ClientID 和 ClientSecret 用于向令牌端点认证适配器自身
+	ClientID     string `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	// Audience 和 Resource 描述目标后端
+	Audience string   `json:"audience,omitempty" yaml:"audience,omitempty"`
+	Resource string   `json:"resource,omitempty" yaml:"resource,omitempty"`
+	Scopes   []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// SubjectTokenType 默认为 access_token
+	SubjectTokenType   string `json:"subject_token_type,omitempty" yaml:"subject_token_type,omitempty"`
+	RequestedTokenType string `json:"requested_token_type,omitempty" yaml:"requested_token_type,omitempty"`
+	// SubjectHeader 指定从调用方的哪个请求头读取主体令牌，默认 Authorization；这个头只用于交换，不转发给后端
+	SubjectHeader string `json:"subject_header,omitempty" yaml:"subject_header,omitempty"`
+	// RefreshBefore 在令牌过期前多久提前刷新，默认 30 秒
+	RefreshBefore time.Duration `json:"refresh_before,omitempty" yaml:"refresh_before,omitempty"`
+	// HTTPClient 访问令牌端点使用的客户端
+	HTTPClient *http.Client `json:"-" yaml:"-"`
+}
+
+// subjectReader 由需要读取调用方请求头的认证提供者实现，这些头会记录在会话身份中供 Authenticate 读取，但不会转发给后端
+type subjectReader interface {
+	subjectHeaders() []string
+}
+
+// sessionCache 由按会话缓存状态的认证提供者实现，会话结束时清理
+type sessionCache interface {
+	forgetSession(sessionID string)
+}
+
+// TokenExchangeProvider 使用会话的主体令牌换取面向后端的令牌，按会话和受众缓存，会话结束时清理。
+// 主体令牌所在的请求头只用于交换，不会转发给后端。
+type TokenExchangeProvider struct {
+	cfg    TokenExchangeConfig
+	mu     sync.Mutex
+	tokens map[string]*exchangedToken
+	calls  map[string]*exchangeCall
+	now    func() time.Time
+}
+
+// exchangeCall 是进行中的一次交换，同一会话的并发调用共享它的结果
+type exchangeCall struct {
+	subject string
+	done    chan struct{}
+	token   *exchangedToken
+	err     error
+}
+
+// exchangedToken 是一次交换得到的后端令牌
+type exchangedToken struct {
+	subject      string
+	accessToken  string
+	tokenType    string
+	refreshToken string
+	expiry       time.Time
+}
+
+// tokenResponse 是令牌端点的响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IssuedTokenType  string `json:"issued_token_type"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewTokenExchangeProvider 创建令牌交换认证提供者
+func NewTokenExchangeProvider(cfg TokenExchangeConfig) *TokenExchangeProvider {
+	if cfg.SubjectTokenType == "" {
+		cfg.SubjectTokenType = tokenTypeAccessToken
+	}
+	if cfg.SubjectHeader == "" {
+		cfg.SubjectHeader = "Authorization"
+	}
+	if cfg.RefreshBefore == 0 {
+		cfg.RefreshBefore = 30 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &TokenExchangeProvider{
+		cfg:    cfg,
+		tokens: make(map[string]*exchangedToken),
+		calls:  make(map[string]*exchangeCall),
+		now:    time.Now,
+	}
+}
+
+// subjectHeaders 实现 subjectReader 接口
+func (p *TokenExchangeProvider) subjectHeaders() []string {
+	return []string{p.cfg.SubjectHeader}
+}
+
+// forgetSession 实现 sessionCache 接口，删除会话的令牌
+func (p *TokenExchangeProvider) forgetSession(sessionID string) {
+	prefix := sessionID + "|"
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.tokens {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.tokens, key)
+		}
+	}
+	for key := range p.calls {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.calls, key)
+		}
+	}
+}
+
+// Authenticate 实现 AuthProvider 接口
+func (p *TokenExchangeProvider) Authenticate(ctx context.Context, req *http.Request) error {
+	subject := IdentityFromContext(ctx).Get(p.cfg.SubjectHeader)
+	if subject == "" {
+		return errors.New("token exchange: no subject token in session identity")
+	}
+	if i := strings.IndexByte(subject, ' '); i > 0 && strings.EqualFold(subject[:i], "bearer") {
+		subject = strings.TrimSpace(subject[i+1:])
+	}
+
+	token, err := p.token(ctx, subject)
+	if err != nil {
+		return err
+	}
+	tokenType := token.tokenType
+	if tokenType == "" || tokenType == "N_A" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+token.accessToken)
+	return nil
+}
+
+// token 返回当前会话可用的后端令牌，必要时交换或刷新；同一会话的并发调用只发起一次交换
+func (p *TokenExchangeProvider) token(ctx context.Context, subject string) (*exchangedToken, error) {
+	key := p.cfg.Audience
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		key = session.SessionID() + "|" + key
+	}
+
+	p.mu.Lock()
+	cached := p.tokens[key]
+	if cached != nil && cached.subject != subject {
+		cached = nil
+	}
+	if cached != nil && (cached.expiry.IsZero() || p.now().Add(p.cfg.RefreshBefore).Before(cached.expiry)) {
+		p.mu.Unlock()
+		return cached, nil
+	}
+	call := p.calls[key]
+	if call == nil || call.subject != subject {
+		call = &exchangeCall{subject: subject, done: make(chan struct{})}
+		p.calls[key] = call
+		// 交换在独立的上下文中进行，发起的调用取消后其他等待者仍能拿到结果
+		exchangeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenExchangeTimeout)
+		go func() {
+			defer cancel()
+			call.token, call.err = p.exchange(exchangeCtx, subject, cached)
+
+			p.mu.Lock()
+			// 交换期间会话已结束时不再缓存令牌
+			if p.calls[key] == call {
+				delete(p.calls, key)
+				if call.err == nil {
+					p.store(key, subject, call.token)
+				}
+			}
+			p.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// exchange 优先用 cached 的 refresh_token 刷新令牌，失败时重新交换
+func (p *TokenExchangeProvider) exchange(ctx context.Context, subject string, cached *exchangedToken) (*exchangedToken, error) {
+	if cached != nil && cached.refreshToken != "" {
+		token, err := p.request(ctx, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {cached.refreshToken},
+		})
+		if err == nil {
+			return token, nil
+		}
+	}
+
+	form := url.Values{
+		"grant_type":         {grantTypeTokenExchange},
+		"subject_token":      {subject},
+		"subject_token_type": {p.cfg.SubjectTokenType},
+	}
+	if p.cfg.Audience != "" {
+		form.Set("audience", p.cfg.Audience)
+	}
+	if p.cfg.Resource != "" {
+		form.Set("resource", p.cfg.Resource)
+	}
+	if len(p.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	}
+	if p.cfg.RequestedTokenType != "" {
+		form.Set("requested_token_type", p.cfg.RequestedTokenType)
+	}
+	return p.request(ctx, form)
+}
+
+// store 缓存令牌并顺带清理已过期的令牌，调用方需持有 mu
+func (p *TokenExchangeProvider) store(key, subject string, token *exchangedToken) {
+	token.subject = subject
+	now := p.now()
+	for k, t := range p.tokens {
+		if !t.expiry.IsZero() && t.expiry.Before(now) && t.refreshToken == "" {
+			delete(p.tokens, k)
+		}
+	}
+	p.tokens[key] = token
+}
+
+// request 调用令牌端点
+func (p *TokenExchangeProvider) request(ctx context.Context, form url.Values) (*exchangedToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("token exchange: unexpected response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		return nil, fmt.Errorf("token exchange: status %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("token exchange: response has no access_token")
+	}
+
+	token := &exchangedToken{
+		accessToken:  tr.AccessToken,
+		tokenType:    tr.TokenType,
+		refreshToken: tr.RefreshToken,
+	}
+	if tr.ExpiresIn > 0 {
+		token.expiry = p.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,164 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenExchangeProvider(t *testing.T) {
+	var exchanges, refreshes int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "adapter", user)
+		assert.Equal(t, "secret", pass)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case grantTypeTokenExchange:
+			atomic.AddInt32(&exchanges, 1)
+			assert.Equal(t, "user-token", r.Form.Get("subject_token"))
+			assert.Equal(t, "orders-api", r.Form.Get("audience"))
+			w.Write([]byte(`{"access_token":"backend-token","token_type":"Bearer","expires_in":60,"refresh_token":"r1"}`))
+		case "refresh_token":
+			atomic.AddInt32(&refreshes, 1)
+			assert.Equal(t, "r1", r.Form.Get("refresh_token"))
+			w.Write([]byte(`{"access_token":"refreshed-token","token_type":"Bearer","expires_in":60}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+		}
+	}))
+	defer tokenServer.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer backend.Close()
+
+	provider := NewTokenExchangeProvider(TokenExchangeConfig{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "adapter",
+		ClientSecret: "secret",
+		Audience:     "orders-api",
+	})
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithIdentityPassthrough(), WithAuthProvider(provider))
+
+	r := httptest.NewRequest(http.MethodPost, "/message?sessionId=s1", nil)
+	r.Header.Set("Authorization", "Bearer user-token")
+	ctx := adapter.sseContextFunc(adapter.server.WithContext(context.Background(), &fakeSession{id: "s1"}), r)
+
+	call := func() string {
+		result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	assert.Equal(t, "Bearer backend-token", call())
+	assert.Equal(t, "Bearer backend-token", call())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&exchanges))
+
+	now = now.Add(45 * time.Second)
+	assert.Equal(t, "Bearer refreshed-token", call())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+}
+
+func TestTokenExchangeProvider_NoSubject(t *testing.T) {
+	provider := NewTokenExchangeProvider(TokenExchangeConfig{TokenURL: "http://127.0.0.1:0"})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Error(t, provider.Authenticate(context.Background(), req))
+}
+
+func TestTokenExchangeProvider_SessionTokens(t *testing.T) {
+	var exchanges int32
+	release := make(chan struct{})
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exchanges, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"backend-token","token_type":"Bearer","expires_in":60}`))
+	}))
+	defer tokenServer.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-User-Token")))
+	}))
+	defer backend.Close()
+
+	provider := NewTokenExchangeProvider(TokenExchangeConfig{TokenURL: tokenServer.URL, SubjectHeader: "X-User-Token"})
+	adapter := newIdentityTestAdapter(t, backend.URL, WithAuthProvider(provider))
+
+	r := httptest.NewRequest(http.MethodPost, "/message?sessionId=s1", nil)
+	r.Header.Set("X-User-Token", "user-token")
+	ctx := adapter.sseContextFunc(adapter.server.WithContext(context.Background(), &fakeSession{id: "s1"}), r)
+	call := func() string {
+		result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	// 同一会话的并发调用只交换一次，主体令牌不转发给后端
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "Bearer backend-token|", call())
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&exchanges))
+
+	// 会话结束后令牌被清理
+	adapter.forgetSession("s1")
+	provider.mu.Lock()
+	assert.Empty(t, provider.tokens)
+	provider.mu.Unlock()
+	assert.Equal(t, "Bearer backend-token|", call())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&exchanges))
+}
+
+func TestTokenExchangeProvider_LeaderCanceled(t *testing.T) {
+	release := make(chan struct{})
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"backend-token","token_type":"Bearer","expires_in":60}`))
+	}))
+	defer tokenServer.Close()
+	provider := NewTokenExchangeProvider(TokenExchangeConfig{TokenURL: tokenServer.URL})
+
+	// 发起交换的调用取消后立即返回，交换继续进行，其他等待者拿到结果
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := provider.token(leaderCtx, "user-token")
+		leaderDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	waiterDone := make(chan *exchangedToken, 1)
+	go func() {
+		token, err := provider.token(context.Background(), "user-token")
+		assert.NoError(t, err)
+		waiterDone <- token
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-leaderDone, context.Canceled)
+
+	close(release)
+	assert.Equal(t, "backend-token", (<-waiterDone).accessToken)
+}
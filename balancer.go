@@ -0,0 +1,335 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// 负载均衡策略
+const (
+	RoundRobin    = "round-robin"
+	LeastInflight = "least-inflight"
+	Weighted      = "weighted"
+)
+
+// Endpoint 是一个后端实例
+type Endpoint struct {
+	URL    string `json:"url" yaml:"url"`
+	Weight int    `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+// LoadBalancerConfig 是多实例后端的负载均衡和健康检查配置
+type LoadBalancerConfig struct {
+	// Strategy 选择策略：round-robin（默认）、least-inflight 或 weighted
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	// HealthCheckPath 主动健康检查的路径，为空时不做主动检查
+	HealthCheckPath string `json:"health_check_path,omitempty" yaml:"health_check_path,omitempty"`
+	// HealthCheckInterval 主动健康检查间隔，默认 10s
+	HealthCheckInterval time.Duration `json:"health_check_interval,omitempty" yaml:"health_check_interval,omitempty"`
+	// HealthCheckTimeout 单次健康检查超时，默认 2s
+	HealthCheckTimeout time.Duration `json:"health_check_timeout,omitempty" yaml:"health_check_timeout,omitempty"`
+	// MaxFails 连续失败多少次后被动摘除实例，默认 3
+	MaxFails int `json:"max_fails,omitempty" yaml:"max_fails,omitempty"`
+	// EjectDuration 被动摘除的时长，默认 30s
+	EjectDuration time.Duration `json:"eject_duration,omitempty" yaml:"eject_duration,omitempty"`
+}
+
+// EndpointState 是后端实例的对外状态
+type EndpointState struct {
+	Healthy  bool `json:"healthy"`
+	Ejected  bool `json:"ejected"`
+	Inflight int  `json:"inflight"`
+}
+
+// WithBackends 使用多个后端实例，替代构造函数中的单个后端地址
+func WithBackends(endpoints ...Endpoint) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.backendEndpoints = endpoints
+	}
+}
+
+// WithLoadBalancer 设置负载均衡和健康检查策略
+func WithLoadBalancer(cfg LoadBalancerConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.balancerConfig = cfg
+	}
+}
+
+// endpoint 是负载均衡中的一个实例及其运行状态
+type endpoint struct {
+	url           string
+	weight        int
+	currentWeight int
+	inflight      int32
+
+	healthy      bool
+	fails        int
+	ejectedUntil time.Time
+}
+
+// endpointSet 是一组后端实例
+type endpointSet struct {
+	cfg    LoadBalancerConfig
+	now    func() time.Time
+	logger *zerolog.Logger
+	// blocked 判断实例的断路器是否打开，打开的实例不参与选择
+	blocked func(url string) bool
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int
+}
+
+// newEndpointSet 创建后端实例集合
+func newEndpointSet(cfg LoadBalancerConfig, endpoints []Endpoint) *endpointSet {
+	if cfg.Strategy == "" {
+		cfg.Strategy = RoundRobin
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 10 * time.Second
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = 2 * time.Second
+	}
+	if cfg.MaxFails <= 0 {
+		cfg.MaxFails = 3
+	}
+	if cfg.EjectDuration <= 0 {
+		cfg.EjectDuration = 30 * time.Second
+	}
+	s := &endpointSet{cfg: cfg, now: time.Now, logger: &log.Logger}
+	s.update(endpoints)
+	return s
+}
+
+// update 替换实例列表，保留仍然存在的实例的运行状态
+func (s *endpointSet) update(endpoints []Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := make(map[string]*endpoint, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		existing[ep.url] = ep
+	}
+
+	updated := make([]*endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		url := strings.TrimSuffix(e.URL, "/")
+		weight := e.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		ep, ok := existing[url]
+		if !ok {
+			ep = &endpoint{url: url, healthy: true}
+		}
+		ep.weight = weight
+		updated = append(updated, ep)
+	}
+	s.endpoints = updated
+}
+
+// urls 返回所有实例地址
+func (s *endpointSet) urls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls := make([]string, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		urls = append(urls, ep.url)
+	}
+	return urls
+}
+
+// available 判断实例当前是否可以接收请求，调用方需持有锁
+func (s *endpointSet) available(ep *endpoint, now time.Time) bool {
+	return ep.healthy && !now.Before(ep.ejectedUntil) && !s.isBlocked(ep)
+}
+
+// isBlocked 判断实例的断路器是否打开
+func (s *endpointSet) isBlocked(ep *endpoint) bool {
+	return s.blocked != nil && s.blocked(ep.url)
+}
+
+// pick 选择一个实例，优先选择可用且本次调用尚未尝试过的实例；断路器打开的实例只在所有实例的断路器都打开时才会选中
+func (s *endpointSet) pick(tried map[*endpoint]bool) *endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.endpoints) == 0 {
+		return nil
+	}
+
+	now := s.now()
+	var candidates []*endpoint
+	for _, filter := range []func(*endpoint) bool{
+		func(ep *endpoint) bool { return !tried[ep] && s.available(ep, now) },
+		func(ep *endpoint) bool { return !tried[ep] && !s.isBlocked(ep) },
+		func(ep *endpoint) bool { return !s.isBlocked(ep) },
+		func(ep *endpoint) bool { return true },
+	} {
+		for _, ep := range s.endpoints {
+			if filter(ep) {
+				candidates = append(candidates, ep)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+
+	switch s.cfg.Strategy {
+	case LeastInflight:
+		var best *endpoint
+		offset := s.next
+		s.next++
+		for i := range candidates {
+			ep := candidates[(offset+i)%len(candidates)]
+			if best == nil || atomic.LoadInt32(&ep.inflight) < atomic.LoadInt32(&best.inflight) {
+				best = ep
+			}
+		}
+		return best
+	case Weighted:
+		// 平滑加权轮询
+		var best *endpoint
+		total := 0
+		for _, ep := range candidates {
+			ep.currentWeight += ep.weight
+			total += ep.weight
+			if best == nil || ep.currentWeight > best.currentWeight {
+				best = ep
+			}
+		}
+		best.currentWeight -= total
+		return best
+	default:
+		ep := candidates[s.next%len(candidates)]
+		s.next++
+		return ep
+	}
+}
+
+// hasUntried 判断是否还有本次调用未尝试过的可用实例
+func (s *endpointSet) hasUntried(tried map[*endpoint]bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for _, ep := range s.endpoints {
+		if !tried[ep] && s.available(ep, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// report 记录一次请求的结果，连续失败达到阈值时被动摘除实例
+func (s *endpointSet) report(ep *endpoint, success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if success {
+		ep.fails = 0
+		return
+	}
+	ep.fails++
+	if ep.fails >= s.cfg.MaxFails {
+		ep.ejectedUntil = s.now().Add(s.cfg.EjectDuration)
+		ep.fails = 0
+		s.logger.Printf("eject backend %s for %s", ep.url, s.cfg.EjectDuration)
+	}
+}
+
+// states 返回所有实例的状态
+func (s *endpointSet) states() map[string]EndpointState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	states := make(map[string]EndpointState, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		states[ep.url] = EndpointState{
+			Healthy:  ep.healthy,
+			Ejected:  now.Before(ep.ejectedUntil),
+			Inflight: int(atomic.LoadInt32(&ep.inflight)),
+		}
+	}
+	return states
+}
+
+// runHealthChecks 定期主动检查所有实例，直到 ctx 结束
+func (s *endpointSet) runHealthChecks(ctx context.Context, client *http.Client) {
+	if s.cfg.HealthCheckPath == "" {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.checkAll(ctx, client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll 对所有实例执行一次健康检查
+func (s *endpointSet) checkAll(ctx context.Context, client *http.Client) {
+	s.mu.Lock()
+	endpoints := append([]*endpoint{}, s.endpoints...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, ep := range endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			healthy := s.check(ctx, client, ep.url)
+			s.mu.Lock()
+			if ep.healthy != healthy {
+				s.logger.Printf("backend %s healthy=%v", ep.url, healthy)
+			}
+			ep.healthy = healthy
+			s.mu.Unlock()
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// check 请求实例的健康检查路径，2xx 和 3xx 视为健康
+func (s *endpointSet) check(ctx context.Context, client *http.Client, url string) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.HealthCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+s.cfg.HealthCheckPath, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusBadRequest
+}
+
+// endpoints 返回适配器的后端实例集合，未配置多实例时使用构造函数中的后端地址
+func (a *OpenAPIToMCPAdapter) endpoints() *endpointSet {
+	if a.backends != nil {
+		return a.backends
+	}
+	s := newEndpointSet(a.balancerConfig, []Endpoint{{URL: a.backendBaseUrl}})
+	s.blocked = a.endpointBlocked
+	return s
+}
+
+// endpointStates 返回后端实例状态，未配置多实例时返回 nil
+func (a *OpenAPIToMCPAdapter) endpointStates() map[string]EndpointState {
+	if a.backends == nil {
+		return nil
+	}
+	return a.backends.states()
+}
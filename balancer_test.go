@@ -0,0 +1,80 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNamedBackend(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && name == "down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(name))
+	}))
+}
+
+func TestLoadBalancer_RoundRobinAndFailover(t *testing.T) {
+	b1 := newNamedBackend("b1")
+	defer b1.Close()
+	b2 := newNamedBackend("b2")
+	defer b2.Close()
+	dead := httptest.NewServer(nil)
+	dead.Close()
+
+	adapter := newIdentityTestAdapter(t, "", WithBackends(Endpoint{URL: b1.URL}, Endpoint{URL: b2.URL}))
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		seen[result.Content[0].(mcp.TextContent).Text]++
+	}
+	assert.Equal(t, map[string]int{"b1": 2, "b2": 2}, seen)
+
+	adapter = newIdentityTestAdapter(t, "",
+		WithBackends(Endpoint{URL: dead.URL}, Endpoint{URL: b2.URL}),
+		WithLoadBalancer(LoadBalancerConfig{MaxFails: 1}),
+	)
+	for i := 0; i < 2; i++ {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, "b2", result.Content[0].(mcp.TextContent).Text)
+	}
+	assert.True(t, adapter.Health().Endpoints[dead.URL].Ejected)
+	assert.Equal(t, "ok", adapter.Health().Status)
+}
+
+func TestLoadBalancer_Weighted(t *testing.T) {
+	set := newEndpointSet(LoadBalancerConfig{Strategy: Weighted}, []Endpoint{
+		{URL: "http://a", Weight: 3},
+		{URL: "http://b", Weight: 1},
+	})
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		counts[set.pick(nil).url]++
+	}
+	assert.Equal(t, map[string]int{"http://a": 6, "http://b": 2}, counts)
+}
+
+func TestLoadBalancer_ActiveHealthCheck(t *testing.T) {
+	up := newNamedBackend("up")
+	defer up.Close()
+	down := newNamedBackend("down")
+	defer down.Close()
+
+	set := newEndpointSet(LoadBalancerConfig{HealthCheckPath: "/health"}, []Endpoint{{URL: up.URL}, {URL: down.URL}})
+	set.checkAll(context.Background(), http.DefaultClient)
+
+	states := set.states()
+	assert.True(t, states[up.URL].Healthy)
+	assert.False(t, states[down.URL].Healthy)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, up.URL, set.pick(nil).url)
+	}
+}
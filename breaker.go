@@ -0,0 +1,263 @@
+package gmadapter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 断路器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerConfig 是断路器配置
+type BreakerConfig struct {
+	// FailureThreshold 连续失败多少次后打开断路器，默认 5
+	FailureThreshold int `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty"`
+	// Cooldown 断路器打开后多久进入半开状态，默认 30s
+	Cooldown time.Duration `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+	// HalfOpenMaxCalls 半开状态下允许同时放行的探测请求数，默认 1
+	HalfOpenMaxCalls int `json:"half_open_max_calls,omitempty" yaml:"half_open_max_calls,omitempty"`
+	// SuccessThreshold 半开状态下连续成功多少次后关闭断路器，默认 1
+	SuccessThreshold int `json:"success_threshold,omitempty" yaml:"success_threshold,omitempty"`
+}
+
+// BreakerState 是断路器的对外状态
+type BreakerState struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenedAt            time.Time `json:"openedAt,omitempty"`
+}
+
+// WithCircuitBreaker 为每个后端实例和每个工具开启断路器，断路器打开的后端实例不参与负载均衡
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		if cfg.FailureThreshold <= 0 {
+			cfg.FailureThreshold = 5
+		}
+		if cfg.Cooldown <= 0 {
+			cfg.Cooldown = 30 * time.Second
+		}
+		if cfg.HalfOpenMaxCalls <= 0 {
+			cfg.HalfOpenMaxCalls = 1
+		}
+		if cfg.SuccessThreshold <= 0 {
+			cfg.SuccessThreshold = 1
+		}
+		a.breakerConfig = &cfg
+	}
+}
+
+// circuitBreaker 是一个按连续失败次数打开的断路器
+type circuitBreaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+}
+
+// newCircuitBreaker 创建断路器
+func newCircuitBreaker(name string, cfg BreakerConfig) *circuitBreaker {
+	return &circuitBreaker{name: name, cfg: cfg, now: time.Now, state: BreakerClosed}
+}
+
+// allow 判断是否放行请求，不放行时返回还需等待的时间
+func (b *circuitBreaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		elapsed := b.now().Sub(b.openedAt)
+		if elapsed < b.cfg.Cooldown {
+			return false, b.cfg.Cooldown - elapsed
+		}
+		b.state = BreakerHalfOpen
+		b.successes = 0
+		b.probes = 0
+	}
+	if b.state == BreakerHalfOpen {
+		if b.probes >= b.cfg.HalfOpenMaxCalls {
+			return false, 0
+		}
+		b.probes++
+	}
+	return true, 0
+}
+
+// record 记录一次放行请求的结果
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+	if success {
+		b.failures = 0
+		if b.state == BreakerHalfOpen {
+			b.successes++
+			if b.successes >= b.cfg.SuccessThreshold {
+				b.state = BreakerClosed
+			}
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// blocked 判断断路器当前是否会拒绝请求，不改变断路器状态
+func (b *circuitBreaker) blocked() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		return b.now().Sub(b.openedAt) < b.cfg.Cooldown
+	case BreakerHalfOpen:
+		return b.probes >= b.cfg.HalfOpenMaxCalls
+	}
+	return false
+}
+
+// rejection 返回断路器拒绝请求时说明原因的错误信息，wait 是 allow 返回的等待时间
+func (b *circuitBreaker) rejection(wait time.Duration) string {
+	state := b.snapshot()
+	if state.State == BreakerHalfOpen {
+		return fmt.Sprintf("circuit breaker %s is half-open and already probing the backend; try again shortly", b.name)
+	}
+	return fmt.Sprintf("circuit breaker %s is open after %d consecutive failures; backend calls are suspended for another %s",
+		b.name, state.ConsecutiveFailures, wait.Round(time.Second))
+}
+
+// abort 放弃一次已放行但未完成的请求，不计入成功或失败
+func (b *circuitBreaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// snapshot 返回断路器当前状态
+func (b *circuitBreaker) snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := BreakerState{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != BreakerClosed {
+		state.OpenedAt = b.openedAt
+	}
+	return state
+}
+
+// breakerFor 返回指定名字的断路器，不存在时创建
+func (a *OpenAPIToMCPAdapter) breakerFor(name string) *circuitBreaker {
+	if a.breakerConfig == nil {
+		return nil
+	}
+	a.breakersMu.Lock()
+	defer a.breakersMu.Unlock()
+	if a.breakers == nil {
+		a.breakers = make(map[string]*circuitBreaker)
+	}
+	b, ok := a.breakers[name]
+	if !ok {
+		b = newCircuitBreaker(name, *a.breakerConfig)
+		a.breakers[name] = b
+	}
+	return b
+}
+
+// operationBreakers 返回工具调用需要经过的工具级断路器，后端实例的断路器在选择实例时检查
+func (a *OpenAPIToMCPAdapter) operationBreakers(op toolOperation) []*circuitBreaker {
+	if a.breakerConfig == nil {
+		return nil
+	}
+	return []*circuitBreaker{a.breakerFor("operation:" + op.toolName)}
+}
+
+// endpointBreaker 返回后端实例的断路器，未开启断路器时返回 nil
+func (a *OpenAPIToMCPAdapter) endpointBreaker(url string) *circuitBreaker {
+	return a.breakerFor("backend:" + url)
+}
+
+// endpointBlocked 判断后端实例的断路器是否打开，供负载均衡跳过该实例
+func (a *OpenAPIToMCPAdapter) endpointBlocked(url string) bool {
+	if a.breakerConfig == nil {
+		return false
+	}
+	return a.endpointBreaker(url).blocked()
+}
+
+// breakerOpenError 表示选中的后端实例的断路器拒绝了请求，即所有实例的断路器都已打开
+type breakerOpenError struct {
+	reason string
+}
+
+// Error 实现 error 接口
+func (e *breakerOpenError) Error() string {
+	return e.reason
+}
+
+// acquireBreakers 依次检查断路器，任一打开时返回说明原因的错误信息
+func acquireBreakers(breakers []*circuitBreaker) ([]*circuitBreaker, string) {
+	var acquired []*circuitBreaker
+	for _, b := range breakers {
+		ok, wait := b.allow()
+		if !ok {
+			for _, prev := range acquired {
+				prev.abort()
+			}
+			return nil, b.rejection(wait)
+		}
+		acquired = append(acquired, b)
+	}
+	return acquired, ""
+}
+
+// releaseBreakers 把调用结果记录到断路器
+func releaseBreakers(breakers []*circuitBreaker, success bool) {
+	for _, b := range breakers {
+		b.record(success)
+	}
+}
+
+// breakerStates 返回所有断路器的状态
+func (a *OpenAPIToMCPAdapter) breakerStates() map[string]BreakerState {
+	a.breakersMu.Lock()
+	defer a.breakersMu.Unlock()
+	if len(a.breakers) == 0 {
+		return nil
+	}
+	states := make(map[string]BreakerState, len(a.breakers))
+	for name, b := range a.breakers {
+		states[name] = b.snapshot()
+	}
+	return states
+}
+
+// breakerGauge 在指标中输出断路器状态（0 关闭、1 半开、2 打开）
+func (a *OpenAPIToMCPAdapter) breakerGauge(emit func(name string, value float64, labels ...string)) {
+	for name, state := range a.breakerStates() {
+		value := 0.0
+		switch state.State {
+		case BreakerHalfOpen:
+			value = 1
+		case BreakerOpen:
+			value = 2
+		}
+		emit("mcp_adapter_circuit_breaker_state", value, "breaker", name)
+	}
+}
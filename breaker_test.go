@@ -0,0 +1,117 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	var healthy, calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithCircuitBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		Cooldown:         50 * time.Millisecond,
+	}))
+	call := func() *mcp.CallToolResult {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		return result
+	}
+
+	call()
+	call()
+	result := call()
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "is open")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	health := adapter.Health()
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, BreakerOpen, health.Breakers["operation:_whoami_get"].State)
+	assert.Equal(t, 2.0, adapter.Metrics()[`mcp_adapter_circuit_breaker_state{breaker="operation:_whoami_get"}`])
+
+	rec := httptest.NewRecorder()
+	adapter.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.True(t, strings.Contains(rec.Body.String(), "mcp_adapter_circuit_breaker_rejections_total"))
+
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	result = call()
+	assert.False(t, result.IsError)
+	assert.Equal(t, "ok", adapter.Health().Status)
+}
+
+func TestCircuitBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	b := newCircuitBreaker("test", BreakerConfig{FailureThreshold: 1, Cooldown: time.Second, HalfOpenMaxCalls: 1, SuccessThreshold: 1})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	ok, _ := b.allow()
+	assert.True(t, ok)
+	b.record(false)
+	assert.Equal(t, BreakerOpen, b.snapshot().State)
+
+	now = now.Add(2 * time.Second)
+	ok, _ = b.allow()
+	assert.True(t, ok)
+	ok, _ = b.allow()
+	assert.False(t, ok)
+
+	b.record(false)
+	assert.Equal(t, BreakerOpen, b.snapshot().State)
+}
+
+func TestCircuitBreaker_PerEndpoint(t *testing.T) {
+	var badCalls int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&badCalls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer good.Close()
+
+	adapter := newIdentityTestAdapter(t, bad.URL,
+		WithBackends(Endpoint{URL: bad.URL}, Endpoint{URL: good.URL}),
+		WithCircuitBreaker(BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}),
+	)
+	for i := 0; i < 6; i++ {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	// 只有第一次调用到达故障实例，之后负载均衡跳过断路器打开的实例
+	assert.Equal(t, int32(1), atomic.LoadInt32(&badCalls))
+	breakers := adapter.Health().Breakers
+	assert.Equal(t, BreakerOpen, breakers["backend:"+bad.URL].State)
+	assert.Equal(t, BreakerClosed, breakers["backend:"+good.URL].State)
+	assert.Equal(t, BreakerClosed, breakers["operation:_whoami_get"].State)
+
+	// 所有实例的断路器都打开时拒绝调用
+	adapter.endpointBreaker(good.URL).record(false)
+	result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "circuit breaker backend:")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&badCalls))
+}
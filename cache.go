@@ -0,0 +1,295 @@
+package gmadapter
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// cacheTTLExtension 是 OpenAPI 操作上声明缓存时间的扩展字段，格式同 x-mcp-timeout，负数表示不缓存
+const cacheTTLExtension = "x-mcp-cache-ttl"
+
+// CacheConfig 是 GET/HEAD 工具的响应缓存配置
+type CacheConfig struct {
+	// MaxEntries 最多缓存的响应数，默认 1000
+	MaxEntries int `json:"max_entries,omitempty" yaml:"max_entries,omitempty"`
+	// MaxBytes 缓存的响应体总大小上限，0 表示不限制
+	MaxBytes int64 `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+	// DefaultTTL 后端未给出 Cache-Control 或 Expires 时的缓存时间，0 表示只缓存可重新验证的响应
+	DefaultTTL time.Duration `json:"default_ttl,omitempty" yaml:"default_ttl,omitempty"`
+	// OperationTTL 按工具名覆盖缓存时间，优先级高于后端响应头和 x-mcp-cache-ttl，负数表示不缓存
+	OperationTTL map[string]time.Duration `json:"operation_ttl,omitempty" yaml:"operation_ttl,omitempty"`
+	// Shared 开启后不同会话共享缓存，否则每个会话单独缓存
+	Shared bool `json:"shared,omitempty" yaml:"shared,omitempty"`
+}
+
+// WithResponseCache 为 GET/HEAD 工具开启响应缓存
+func WithResponseCache(cfg CacheConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		if cfg.MaxEntries <= 0 {
+			cfg.MaxEntries = 1000
+		}
+		a.cache = newResponseCache(cfg)
+	}
+}
+
+// cacheEntry 是一条缓存的后端响应
+type cacheEntry struct {
+	key          string
+	statusCode   int
+	header       http.Header
+	body         []byte
+	expires      time.Time
+	etag         string
+	lastModified string
+}
+
+// revalidatable 判断缓存是否可以通过条件请求重新验证
+func (e *cacheEntry) revalidatable() bool {
+	return e.etag != "" || e.lastModified != ""
+}
+
+// responseCache 是按 LRU 淘汰的响应缓存
+type responseCache struct {
+	cfg CacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	bytes   int64
+}
+
+// newResponseCache 创建响应缓存
+func newResponseCache(cfg CacheConfig) *responseCache {
+	return &responseCache{
+		cfg:     cfg,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// key 生成缓存键：方法、请求路径、透传给后端的身份头以及会话
+func (c *responseCache) key(ctx context.Context, method, url string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(method))
+	b.WriteByte(' ')
+	b.WriteString(url)
+
+	b.WriteString(identitySignature(ctx))
+
+	if !c.cfg.Shared {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			b.WriteString("\nsession: " + session.SessionID())
+		}
+	}
+	return b.String()
+}
+
+// get 查找缓存，返回缓存条目以及它是否仍然新鲜；过期且无法重新验证的条目会被删除
+func (c *responseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.now().Before(entry.expires) {
+		c.lru.MoveToFront(el)
+		return entry, true
+	}
+	if !entry.revalidatable() {
+		c.removeElement(el)
+		return nil, false
+	}
+	return entry, false
+}
+
+// put 写入缓存并按条数和大小淘汰最久未使用的条目
+func (c *responseCache) put(entry *cacheEntry) {
+	size := int64(len(entry.body))
+	if c.cfg.MaxBytes > 0 && size > c.cfg.MaxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[entry.key]; ok {
+		c.removeElement(el)
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.bytes += size
+
+	for c.lru.Len() > c.cfg.MaxEntries || (c.cfg.MaxBytes > 0 && c.bytes > c.cfg.MaxBytes) {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// removeElement 删除一个缓存条目，调用方需持有锁
+func (c *responseCache) removeElement(el *list.Element) {
+	entry := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= int64(len(entry.body))
+}
+
+// stats 返回缓存的条目数和响应体总大小
+func (c *responseCache) stats() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len(), c.bytes
+}
+
+// freshness 根据 Cache-Control、Expires 和 Age 计算响应的新鲜时间；
+// explicit 表示后端给出了缓存指令，store 为 false 表示不允许缓存
+func (c *responseCache) freshness(header http.Header, now time.Time) (ttl time.Duration, explicit, store bool) {
+	directives := parseCacheControl(header)
+	if _, ok := directives["no-store"]; ok {
+		return 0, true, false
+	}
+	if _, ok := directives["private"]; ok && c.cfg.Shared {
+		return 0, true, false
+	}
+
+	switch {
+	case hasDirective(directives, "no-cache"):
+		return 0, true, true
+	case hasDirective(directives, "s-maxage") && c.cfg.Shared:
+		ttl, explicit = parseSeconds(directives["s-maxage"]), true
+	case hasDirective(directives, "max-age"):
+		ttl, explicit = parseSeconds(directives["max-age"]), true
+	case header.Get("Expires") != "":
+		explicit = true
+		expires, err := http.ParseTime(header.Get("Expires"))
+		if err != nil {
+			return 0, true, true
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		ttl = expires.Sub(date)
+	}
+	if explicit {
+		if age := parseSeconds(header.Get("Age")); age > 0 {
+			ttl -= age
+		}
+		if ttl < 0 {
+			ttl = 0
+		}
+	}
+	return ttl, explicit, true
+}
+
+// hasDirective 判断 Cache-Control 是否包含指定指令
+func hasDirective(directives map[string]string, name string) bool {
+	_, ok := directives[name]
+	return ok
+}
+
+// parseCacheControl 解析 Cache-Control 头为指令表
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, v := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, ""
+			if i := strings.IndexByte(part, '='); i >= 0 {
+				name, value = part[:i], strings.Trim(part[i+1:], `"`)
+			}
+			directives[strings.ToLower(name)] = value
+		}
+	}
+	return directives
+}
+
+// parseSeconds 解析以秒为单位的非负整数，无效时返回 0
+func parseSeconds(v string) time.Duration {
+	secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// operationCacheTTL 计算工具的缓存时间覆盖：显式配置 > x-mcp-cache-ttl，0 表示按响应头决定
+func (a *OpenAPIToMCPAdapter) operationCacheTTL(toolName string, operationMap map[string]interface{}) time.Duration {
+	if a.cache == nil {
+		return 0
+	}
+	if d, ok := a.cache.cfg.OperationTTL[toolName]; ok {
+		return d
+	}
+	if v, ok := operationMap[cacheTTLExtension]; ok {
+		d, err := parseTimeout(v)
+		if err == nil {
+			return d
+		}
+		a.log().Printf("invalid %s for %s: %v", cacheTTLExtension, toolName, err)
+	}
+	return 0
+}
+
+// cacheable 判断工具调用是否经过响应缓存
+func (a *OpenAPIToMCPAdapter) cacheable(op toolOperation) bool {
+	return a.cache != nil && op.cacheTTL >= 0 && (op.method == "get" || op.method == "head")
+}
+
+// storeResponse 按缓存规则保存后端响应；304 响应用于刷新 previous 条目
+func (a *OpenAPIToMCPAdapter) storeResponse(op toolOperation, key string, previous *cacheEntry, resp *backendResponse) {
+	entry := &cacheEntry{key: key, statusCode: resp.statusCode, header: resp.header, body: resp.body}
+	if resp.statusCode == http.StatusNotModified && previous != nil {
+		header := previous.header.Clone()
+		for name, v := range resp.header {
+			header[name] = v
+		}
+		entry.statusCode, entry.header, entry.body = previous.statusCode, header, previous.body
+	}
+	if entry.statusCode != http.StatusOK {
+		return
+	}
+
+	now := a.cache.now()
+	ttl, explicit, store := a.cache.freshness(entry.header, now)
+	if !store {
+		return
+	}
+	switch {
+	case op.cacheTTL > 0:
+		ttl = op.cacheTTL
+	case !explicit:
+		ttl = a.cache.cfg.DefaultTTL
+	}
+	entry.expires = now.Add(ttl)
+	entry.etag = entry.header.Get("ETag")
+	entry.lastModified = entry.header.Get("Last-Modified")
+	if ttl <= 0 && !entry.revalidatable() {
+		return
+	}
+	a.cache.put(entry)
+}
+
+// cachedResult 用缓存的响应构造工具调用结果
+func cachedResult(entry *cacheEntry, status string) *mcp.CallToolResult {
+	result := mcp.NewToolResultText(string(entry.body))
+	result.Meta = map[string]interface{}{"cache": status}
+	return result
+}
+
+// cacheGauge 在指标中输出缓存条目数和大小
+func (a *OpenAPIToMCPAdapter) cacheGauge(emit func(name string, value float64, labels ...string)) {
+	entries, bytes := a.cache.stats()
+	emit("mcp_adapter_cache_entries", float64(entries))
+	emit("mcp_adapter_cache_bytes", float64(bytes))
+}
@@ -0,0 +1,136 @@
+package gmadapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache_MaxAgeAndSessions(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "v%d", n)
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithResponseCache(CacheConfig{}))
+	call := func(ctx context.Context) *mcp.CallToolResult {
+		result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		return result
+	}
+
+	alice := adapter.server.WithContext(context.Background(), &fakeSession{id: "alice"})
+	bob := adapter.server.WithContext(context.Background(), &fakeSession{id: "bob"})
+	assert.Equal(t, "v1", call(alice).Content[0].(mcp.TextContent).Text)
+	result := call(alice)
+	assert.Equal(t, "v1", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, "hit", result.Meta["cache"])
+	assert.Equal(t, "v2", call(bob).Content[0].(mcp.TextContent).Text)
+
+	adapter.cache.now = func() time.Time { return time.Now().Add(time.Minute) }
+	assert.Equal(t, "v3", call(alice).Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1.0, adapter.Metrics()[`mcp_adapter_cache_requests_total{tool="_whoami_get",result="hit"}`])
+}
+
+func TestResponseCache_ETagRevalidation(t *testing.T) {
+	var calls, notModified int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("body"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithResponseCache(CacheConfig{}))
+	for _, want := range []interface{}{nil, "revalidated", "revalidated"} {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, "body", result.Content[0].(mcp.TextContent).Text)
+		assert.Equal(t, want, result.Meta["cache"])
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+}
+
+func TestResponseCache_NoStoreAndOperationTTL(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithResponseCache(CacheConfig{
+		DefaultTTL:   time.Minute,
+		OperationTTL: map[string]time.Duration{"_whoami_get": time.Hour},
+	}))
+	for i := 0; i < 2; i++ {
+		_, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestResponseCache_LRUEviction(t *testing.T) {
+	cache := newResponseCache(CacheConfig{MaxEntries: 2, MaxBytes: 10})
+	expires := time.Now().Add(time.Hour)
+	cache.put(&cacheEntry{key: "a", body: []byte("aaa"), expires: expires})
+	cache.put(&cacheEntry{key: "b", body: []byte("bbb"), expires: expires})
+	_, fresh := cache.get("a")
+	assert.True(t, fresh)
+
+	cache.put(&cacheEntry{key: "c", body: []byte("ccc"), expires: expires})
+	_, fresh = cache.get("b")
+	assert.False(t, fresh)
+	entries, size := cache.stats()
+	assert.Equal(t, 2, entries)
+	assert.Equal(t, int64(6), size)
+
+	cache.put(&cacheEntry{key: "d", body: []byte("dddddddd"), expires: expires})
+	_, fresh = cache.get("a")
+	assert.False(t, fresh)
+	entries, size = cache.stats()
+	assert.Equal(t, 1, entries)
+	assert.Equal(t, int64(8), size)
+}
+
+func TestResponseCache_Freshness(t *testing.T) {
+	cache := newResponseCache(CacheConfig{Shared: true})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ttl, explicit, store := cache.freshness(http.Header{"Cache-Control": {"max-age=60, s-maxage=120"}, "Age": {"20"}}, now)
+	assert.Equal(t, 100*time.Second, ttl)
+	assert.True(t, explicit)
+	assert.True(t, store)
+
+	ttl, _, _ = cache.freshness(http.Header{
+		"Date":    {now.Format(http.TimeFormat)},
+		"Expires": {now.Add(time.Hour).Format(http.TimeFormat)},
+	}, now)
+	assert.Equal(t, time.Hour, ttl)
+
+	_, _, store = cache.freshness(http.Header{"Cache-Control": {"private, max-age=60"}}, now)
+	assert.False(t, store)
+
+	_, explicit, store = cache.freshness(http.Header{}, now)
+	assert.False(t, explicit)
+	assert.True(t, store)
+}
@@ -0,0 +1,132 @@
+package gmadapter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// timeoutExtension 是 OpenAPI 操作上声明超时时间的扩展字段，支持 "5s" 形式或秒数
+const timeoutExtension = "x-mcp-timeout"
+
+// WithDefaultTimeout 设置后端调用的默认超时时间
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.defaultTimeout = d
+	}
+}
+
+// WithOperationTimeout 为指定工具设置超时时间，优先级高于 x-mcp-timeout
+func WithOperationTimeout(toolName string, d time.Duration) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		if a.operationTimeouts == nil {
+			a.operationTimeouts = make(map[string]time.Duration)
+		}
+		a.operationTimeouts[toolName] = d
+	}
+}
+
+// operationTimeout 计算工具的超时时间：显式配置 > x-mcp-timeout > 默认值
+func (a *OpenAPIToMCPAdapter) operationTimeout(toolName string, operationMap map[string]interface{}) time.Duration {
+	if d, ok := a.operationTimeouts[toolName]; ok {
+		return d
+	}
+	if v, ok := operationMap[timeoutExtension]; ok {
+		d, err := parseTimeout(v)
+		if err == nil {
+			return d
+		}
+		a.log().Printf("invalid %s for %s: %v", timeoutExtension, toolName, err)
+	}
+	return a.defaultTimeout
+}
+
+// parseTimeout 解析 "1m30s" 形式的时长或以秒为单位的数字
+func parseTimeout(v interface{}) (time.Duration, error) {
+	switch t := v.(type) {
+	case int:
+		return time.Duration(t) * time.Second, nil
+	case int64:
+		return time.Duration(t) * time.Second, nil
+	case float64:
+		return time.Duration(t * float64(time.Second)), nil
+	case string:
+		if secs, err := strconv.ParseFloat(t, 64); err == nil {
+			return time.Duration(secs * float64(time.Second)), nil
+		}
+		return time.ParseDuration(t)
+	default:
+		return 0, fmt.Errorf("unsupported timeout value %v", v)
+	}
+}
+
+// callKey 生成会话内请求的唯一键
+func callKey(ctx context.Context, id any) string {
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	return fmt.Sprintf("%s|%v", sessionID, id)
+}
+
+// beforeCallTool 记录本次调用的 JSON-RPC 请求 ID，处理函数会通过同一个 ctx 取回
+func (a *OpenAPIToMCPAdapter) beforeCallTool(ctx context.Context, id any, _ *mcp.CallToolRequest) {
+	a.callIDs.Store(ctx, id)
+}
+
+// afterCallTool 清理未被处理函数取走的请求 ID
+func (a *OpenAPIToMCPAdapter) afterCallTool(ctx context.Context, _ any, _ *mcp.CallToolRequest, _ *mcp.CallToolResult) {
+	a.callIDs.Delete(ctx)
+}
+
+// onCallToolError 清理调用失败时残留的请求 ID
+func (a *OpenAPIToMCPAdapter) onCallToolError(ctx context.Context, _ any, method mcp.MCPMethod, _ any, _ error) {
+	if method == mcp.MethodToolsCall {
+		a.callIDs.Delete(ctx)
+	}
+}
+
+// trackedCallKey 标记已经登记过的调用。合并进来的工具依次经过宿主和 other 的处理函数，只由宿主登记一次
+type trackedCallKey struct{}
+
+// trackCall 为工具调用派生可取消的上下文并登记为进行中的调用；
+// 能取到请求 ID 时还按会话和请求 ID 登记，以便响应 notifications/cancelled
+func (a *OpenAPIToMCPAdapter) trackCall(ctx context.Context, toolName string) (context.Context, context.CancelFunc) {
+	if ctx.Value(trackedCallKey{}) != nil {
+		return context.WithCancel(ctx)
+	}
+	id, ok := a.callIDs.LoadAndDelete(ctx)
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, trackedCallKey{}, true))
+	unregister := a.registerCall(ctx, toolName, cancel)
+	if !ok {
+		return ctx, func() {
+			unregister()
+			cancel()
+		}
+	}
+
+	key := callKey(ctx, id)
+	a.inflightCalls.Store(key, cancel)
+	return ctx, func() {
+		a.inflightCalls.Delete(key)
+		unregister()
+		cancel()
+	}
+}
+
+// handleCancelled 处理客户端发来的 notifications/cancelled，取消对应的后端请求
+func (a *OpenAPIToMCPAdapter) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	if cancel, ok := a.inflightCalls.LoadAndDelete(callKey(ctx, id)); ok {
+		reason, _ := notification.Params.AdditionalFields["reason"].(string)
+		a.log().Info().Msgf("cancel tool call %v: %s", id, reason)
+		cancel.(context.CancelFunc)()
+	}
+}
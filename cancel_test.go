@@ -0,0 +1,95 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSlowTestAdapter(t *testing.T, backendURL string, operationMap map[string]interface{}, opts ...Option) *OpenAPIToMCPAdapter {
+	adapter, err := NewOpenAPIToMCPAdapter("test", "1.0.0", backendURL, "localhost:0", opts...)
+	assert.NoError(t, err)
+	adapter.openAPI = map[string]interface{}{
+		"paths": map[string]interface{}{
+			"/slow": map[string]interface{}{"get": operationMap},
+		},
+	}
+	assert.NoError(t, adapter.GenerateTools())
+	adapter.registerTools()
+	return adapter
+}
+
+func TestCancelledNotification_AbortsBackendCall(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer backend.Close()
+
+	adapter := newSlowTestAdapter(t, backend.URL, map[string]interface{}{"summary": "slow"})
+	ctx := adapter.server.WithContext(context.Background(), &fakeSession{id: "s1"})
+
+	done := make(chan mcp.JSONRPCMessage)
+	go func() {
+		done <- adapter.server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"_slow_get"}}`))
+	}()
+
+	<-started
+	adapter.server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user abort"}}`))
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("backend request was not cancelled")
+	}
+	response := <-done
+	_, isError := response.(mcp.JSONRPCError)
+	assert.True(t, isError)
+}
+
+func TestOperationTimeout(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer backend.Close()
+
+	adapter := newSlowTestAdapter(t, backend.URL, map[string]interface{}{
+		"summary":        "slow",
+		timeoutExtension: "50ms",
+	})
+
+	result, err := adapter.handlers["_slow_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	adapter = newSlowTestAdapter(t, backend.URL, map[string]interface{}{timeoutExtension: 30},
+		WithOperationTimeout("_slow_get", 20*time.Millisecond))
+	start := time.Now()
+	result, err = adapter.handlers["_slow_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestParseTimeout(t *testing.T) {
+	for input, want := range map[interface{}]time.Duration{
+		"1m30s": 90 * time.Second,
+		"2":     2 * time.Second,
+		1.5:     1500 * time.Millisecond,
+		3:       3 * time.Second,
+	} {
+		got, err := parseTimeout(input)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := parseTimeout("soon")
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	gmadapter "github.com/zhu733756/go-mcp-adapter"
+)
+
+// envPrefix 是覆盖配置的环境变量前缀
+const envPrefix = "GO_MCP_ADAPTER_"
+
+// Config 是 serve 命令的配置：配置文件描述的适配器配置加上日志级别
+type Config struct {
+	gmadapter.Config
+	// LogLevel 日志级别：debug、info、warn、error
+	LogLevel string
+}
+
+// defaultConfig 返回默认配置
+func defaultConfig() Config {
+	return Config{
+		Config:   gmadapter.Config{Version: version},
+		LogLevel: "info",
+	}
+}
+
+// loadConfigFile 加载配置文件，文件中未给出的版本号使用命令的版本
+func loadConfigFile(path string, cfg *Config) error {
+	loaded, err := gmadapter.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if loaded.Version == "" {
+		loaded.Version = cfg.Version
+	}
+	cfg.Config = *loaded
+	return nil
+}
+
+// singleAPI 返回 --spec 和 --backend 作用的 API，配置中没有 API 时创建一个名为 default 的 API
+func (c *Config) singleAPI(source string) (*gmadapter.APIConfig, error) {
+	switch len(c.APIs) {
+	case 0:
+		c.APIs = append(c.APIs, gmadapter.APIConfig{Name: "default"})
+		return &c.APIs[0], nil
+	case 1:
+		return &c.APIs[0], nil
+	default:
+		return nil, fmt.Errorf("%s can only be used when the config describes a single API, found %d", source, len(c.APIs))
+	}
+}
+
+// applyEnv 用 GO_MCP_ADAPTER_* 环境变量覆盖配置
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	strs := map[string]*string{
+		"NAME":      &cfg.Name,
+		"VERSION":   &cfg.Version,
+		"LISTEN":    &cfg.Listen,
+		"BASE_PATH": &cfg.BasePath,
+		"LOG_LEVEL": &cfg.LogLevel,
+	}
+	for name, field := range strs {
+		if v, ok := lookup(envPrefix + name); ok {
+			*field = v
+		}
+	}
+	if v, ok := lookup(envPrefix + "TRANSPORTS"); ok {
+		cfg.Transports = splitList(v)
+	}
+	if v, ok := lookup(envPrefix + "SHUTDOWN_GRACE"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%sSHUTDOWN_GRACE: %w", envPrefix, err)
+		}
+		cfg.ShutdownGrace = d
+	}
+	for _, name := range []string{"SPEC", "BACKEND"} {
+		v, ok := lookup(envPrefix + name)
+		if !ok {
+			continue
+		}
+		api, err := cfg.singleAPI(envPrefix + name)
+		if err != nil {
+			return err
+		}
+		if name == "SPEC" {
+			api.Spec = v
+		} else {
+			api.Backend = v
+		}
+	}
+	return nil
+}
+
+// splitList 按逗号拆分列表并去掉空项
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	gmadapter "github.com/zhu733756/go-mcp-adapter"
+)
+
+func envMap(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestServeFlags_Precedence(t *testing.T) {
+	path := writeFile(t, "adapter.yaml", `
+listen: ":9000"
+transports: [sse, websocket]
+shutdown_grace: 10s
+apis:
+  - name: users
+    spec: ./openapi.yaml
+    backend: http://file:8080
+`)
+	env := envMap(map[string]string{
+		envPrefix + "BACKEND":    "http://env:8080",
+		envPrefix + "LISTEN":     ":9100",
+		envPrefix + "TRANSPORTS": "stdio, streamable-http",
+	})
+
+	cfg, err := serveFlags([]string{"--config", path, "--listen", ":9200"}, ioutil.Discard, env)
+	assert.NoError(t, err)
+	assert.Equal(t, "users", cfg.APIs[0].Name)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "openapi.yaml"), cfg.APIs[0].Spec)
+	assert.Equal(t, "http://env:8080", cfg.APIs[0].Backend)
+	assert.Equal(t, ":9200", cfg.Listen)
+	assert.Equal(t, []string{"stdio", "streamable-http"}, cfg.Transports)
+	assert.Equal(t, 10*time.Second, cfg.ShutdownGrace)
+	assert.Equal(t, version, cfg.Version)
+}
+
+func TestServeFlags_SingleAPIFromFlags(t *testing.T) {
+	cfg, err := serveFlags([]string{"--spec", "a.yaml", "--backend", "http://localhost:8080"}, ioutil.Discard, envMap(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "default", cfg.APIs[0].Name)
+	assert.Equal(t, "a.yaml", cfg.APIs[0].Spec)
+
+	path := writeFile(t, "adapter.yaml", `
+apis:
+  - {name: a, spec: a.yaml, backend: "http://a"}
+  - {name: b, spec: b.yaml, backend: "http://b"}
+`)
+	_, err = serveFlags([]string{"--config", path, "--backend", "http://c"}, ioutil.Discard, envMap(nil))
+	assert.ErrorContains(t, err, "--backend can only be used when the config describes a single API")
+}
+
+func TestServeFlags_ConfigFromEnvAndJSON(t *testing.T) {
+	path := writeFile(t, "adapter.json", `{"base_path": "/api", "apis": [{"name": "users", "spec": "https://example.com/openapi.json", "backend": "http://json:8080"}]}`)
+
+	cfg, err := serveFlags(nil, ioutil.Discard, envMap(map[string]string{envPrefix + "CONFIG": path}))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/openapi.json", cfg.APIs[0].Spec)
+	assert.Equal(t, "/api", cfg.BasePath)
+}
+
+func TestServeFlags_Errors(t *testing.T) {
+	_, err := serveFlags([]string{"--backend", "http://localhost:8080"}, ioutil.Discard, envMap(nil))
+	assert.ErrorContains(t, err, "apis[0].spec: is required")
+
+	path := writeFile(t, "adapter.yaml", "apis:\n  - name: a\n    spec: a.yaml\n    bakend: http://typo\n")
+	_, err = serveFlags([]string{"--config", path}, ioutil.Discard, envMap(nil))
+	assert.ErrorContains(t, err, "bakend")
+
+	_, err = serveFlags([]string{"--spec", "a.yaml", "--backend", "http://b"}, ioutil.Discard,
+		envMap(map[string]string{envPrefix + "SHUTDOWN_GRACE": "soon"}))
+	assert.ErrorContains(t, err, "SHUTDOWN_GRACE")
+
+	_, err = serveFlags([]string{"--nope"}, ioutil.Discard, envMap(nil))
+	assert.Equal(t, errUsage, err)
+}
+
+func TestNewAdapter(t *testing.T) {
+	spec := writeFile(t, "openapi.yaml", `
+openapi: 3.0.0
+paths:
+  /users:
+    get:
+      summary: List users
+`)
+	cfg := defaultConfig()
+	cfg.APIs = []gmadapter.APIConfig{{Name: "users", Spec: spec, Backend: "http://localhost:8080"}}
+	adapter, err := newAdapter(cfg, zerolog.Nop())
+	assert.NoError(t, err)
+	assert.NotNil(t, adapter.Handler())
+
+	cfg.APIs[0].Spec = filepath.Join(t.TempDir(), "missing.yaml")
+	_, err = newAdapter(cfg, zerolog.Nop())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run(context.Background(), []string{"version"}, &stdout, &stderr))
+	assert.Equal(t, version+"\n", stdout.String())
+
+	assert.Equal(t, 2, run(context.Background(), []string{"bogus"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown command "bogus"`)
+
+	stderr.Reset()
+	assert.Equal(t, 1, run(context.Background(), []string{"serve"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "apis: at least one API is required")
+
+	stdout.Reset()
+	assert.Equal(t, 0, run(context.Background(), []string{"schema"}, &stdout, &stderr))
+	assert.Equal(t, string(gmadapter.ConfigSchema()), stdout.String())
+
+	stdout.Reset()
+	args := []string{"validate", "--spec", "a.yaml", "--backend", "http://localhost:8080"}
+	assert.Equal(t, 0, run(context.Background(), args, &stdout, &stderr))
+	assert.Equal(t, "config is valid\n", stdout.String())
+}
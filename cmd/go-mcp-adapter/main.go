@@ -0,0 +1,77 @@
+// go-mcp-adapter 把 OpenAPI 描述的后端服务以 MCP 服务器的形式对外提供，无需编写 Go 代码。
+//
+// 用法：
+//
+//	go-mcp-adapter serve --spec openapi.yaml --backend http://localhost:8080 --listen :8081
+//	go-mcp-adapter serve --config adapter.yaml
+//	go-mcp-adapter validate --config adapter.yaml
+//	go-mcp-adapter schema > config.schema.json
+//
+// 配置的优先级从低到高依次为：默认值、配置文件、环境变量、命令行参数。
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	gmadapter "github.com/zhu733756/go-mcp-adapter"
+)
+
+// version 在构建时通过 -ldflags "-X main.version=..." 注入
+var version = "dev"
+
+const usage = `Usage: go-mcp-adapter <command> [flags]
+
+Commands:
+  serve     load an OpenAPI spec and serve it as MCP tools
+  validate  check the serve configuration without starting the server
+  schema    print the JSON Schema of the config file
+  version   print the version
+
+Run "go-mcp-adapter serve -h" for the serve flags; validate takes the same flags.
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 执行子命令并返回进程退出码
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "serve":
+		if err := serve(ctx, args[1:], stderr); err != nil {
+			if err != errUsage {
+				fmt.Fprintf(stderr, "go-mcp-adapter: %v\n", err)
+			}
+			return 1
+		}
+		return 0
+	case "validate":
+		if _, err := serveFlags(args[1:], stderr, os.LookupEnv); err != nil {
+			if err != errUsage {
+				fmt.Fprintf(stderr, "go-mcp-adapter: %v\n", err)
+			}
+			return 1
+		}
+		fmt.Fprintln(stdout, "config is valid")
+		return 0
+	case "schema":
+		stdout.Write(gmadapter.ConfigSchema())
+		return 0
+	case "version":
+		fmt.Fprintln(stdout, version)
+		return 0
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "go-mcp-adapter: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
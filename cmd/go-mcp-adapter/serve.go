@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	gmadapter "github.com/zhu733756/go-mcp-adapter"
+)
+
+// errUsage 表示参数错误，用法已经输出过
+var errUsage = errors.New("usage")
+
+// serveFlags 解析 serve 命令的参数，返回校验过的最终配置
+func serveFlags(args []string, stderr io.Writer, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := defaultConfig()
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "", "YAML or JSON config file (env "+envPrefix+"CONFIG)")
+	var flags Config
+	var spec, backend, transports string
+	fs.StringVar(&spec, "spec", "", "OpenAPI spec path or URL")
+	fs.StringVar(&backend, "backend", "", "backend base URL")
+	fs.StringVar(&flags.Listen, "listen", ":8081", "listen address for the HTTP transports")
+	fs.StringVar(&transports, "transport", "sse", "comma-separated transports: sse, stdio, streamable-http, websocket")
+	fs.StringVar(&flags.BasePath, "base-path", "", "base path of the MCP endpoints")
+	fs.StringVar(&flags.Name, "name", "go-mcp-adapter", "server name reported to MCP clients")
+	fs.DurationVar(&flags.ShutdownGrace, "shutdown-grace", 30*time.Second, "how long to wait for in-flight calls on shutdown")
+	fs.StringVar(&flags.LogLevel, "log-level", cfg.LogLevel, "log level: debug, info, warn, error")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: go-mcp-adapter serve [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return cfg, errUsage
+	}
+
+	if *configPath == "" {
+		*configPath, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+	if *configPath != "" {
+		if err := loadConfigFile(*configPath, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if err := applyEnv(&cfg, lookupEnv); err != nil {
+		return cfg, err
+	}
+
+	// 只有显式给出的参数才覆盖配置文件和环境变量
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "spec", "backend":
+			api, apiErr := cfg.singleAPI("--" + f.Name)
+			if apiErr != nil {
+				err = apiErr
+				return
+			}
+			if f.Name == "spec" {
+				api.Spec = spec
+			} else {
+				api.Backend = backend
+			}
+		case "listen":
+			cfg.Listen = flags.Listen
+		case "transport":
+			cfg.Transports = splitList(transports)
+		case "base-path":
+			cfg.BasePath = flags.BasePath
+		case "name":
+			cfg.Name = flags.Name
+		case "shutdown-grace":
+			cfg.ShutdownGrace = flags.ShutdownGrace
+		case "log-level":
+			cfg.LogLevel = flags.LogLevel
+		}
+	})
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// serve 加载 OpenAPI 文档并启动 MCP 服务，收到 SIGINT 或 SIGTERM 后优雅退出，再次收到信号时立即退出
+func serve(ctx context.Context, args []string, stderr io.Writer) error {
+	cfg, err := serveFlags(args, stderr, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	level, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q", cfg.LogLevel)
+	}
+	// stdio 传输占用标准输出，日志只写到标准错误
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: stderr}).Level(level).With().Timestamp().Logger()
+
+	adapter, err := newAdapter(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		sig := <-signals
+		logger.Info().Msgf("received %s, shutting down", sig)
+		cancel()
+		sig = <-signals
+		logger.Warn().Msgf("received %s again, exiting immediately", sig)
+		os.Exit(1)
+	}()
+
+	return adapter.Start(ctx)
+}
+
+// newAdapter 按配置创建适配器并从 OpenAPI 文档生成工具
+func newAdapter(cfg Config, logger zerolog.Logger) (*gmadapter.OpenAPIToMCPAdapter, error) {
+	return gmadapter.NewFromConfig(&cfg.Config, gmadapter.WithLogger(logger))
+}
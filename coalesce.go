@@ -0,0 +1,145 @@
+package gmadapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// coalesceExtension 是 OpenAPI 操作上开启或关闭请求合并的扩展字段
+const coalesceExtension = "x-mcp-coalesce"
+
+// WithRequestCoalescing 让相同的并发安全调用共享一次后端请求。
+// 不指定工具名时对所有 GET/HEAD 工具生效，可以用 x-mcp-coalesce: false 单独关闭；
+// 指定工具名时只对这些工具生效。合并写请求会丢失写入，POST、PUT 等方法的工具始终不合并。
+func WithRequestCoalescing(toolNames ...string) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.coalescing = &coalesceConfig{tools: make(map[string]bool)}
+		for _, name := range toolNames {
+			a.coalescing.tools[name] = true
+		}
+	}
+}
+
+// coalesceConfig 是请求合并配置
+type coalesceConfig struct {
+	tools map[string]bool
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight 是一次正在进行、可被多个调用共享的后端请求
+type flight struct {
+	done    chan struct{}
+	result  *mcp.CallToolResult
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// operationCoalesce 判断工具是否开启请求合并：显式工具名 > x-mcp-coalesce > GET/HEAD 默认开启，只合并安全方法
+func (a *OpenAPIToMCPAdapter) operationCoalesce(toolName, method string, operationMap map[string]interface{}) bool {
+	if a.coalescing == nil {
+		return false
+	}
+	if len(a.coalescing.tools) > 0 {
+		if a.coalescing.tools[toolName] && !isSafe(method) {
+			a.log().Printf("not coalescing %s: %s is not a safe method", toolName, strings.ToUpper(method))
+			return false
+		}
+		return a.coalescing.tools[toolName]
+	}
+	if v, ok := operationMap[coalesceExtension]; ok {
+		enabled, ok := v.(bool)
+		if ok {
+			return enabled && isSafe(method)
+		}
+		a.log().Printf("invalid %s for %s: %v", coalesceExtension, toolName, v)
+	}
+	return isSafe(method)
+}
+
+// isSafe 判断 HTTP 方法是否只读
+func isSafe(method string) bool {
+	switch strings.ToLower(method) {
+	case "get", "head", "options":
+		return true
+	}
+	return false
+}
+
+// coalesce 合并相同身份对同一请求的并发调用。共享请求不随某一个调用方取消，
+// 只有所有等待者都离开后才取消；超时沿用发起者的截止时间。
+func (a *OpenAPIToMCPAdapter) coalesce(ctx context.Context, op toolOperation, url string, body []byte, fn func(context.Context) (*mcp.CallToolResult, error)) (*mcp.CallToolResult, error) {
+	key := strings.ToUpper(op.method) + " " + url + "\n" + string(body) + identitySignature(ctx)
+	c := a.coalescing
+
+	c.mu.Lock()
+	if c.flights == nil {
+		c.flights = make(map[string]*flight)
+	}
+	f, shared := c.flights[key]
+	if shared {
+		f.waiters++
+	} else {
+		shareCtx := context.WithoutCancel(ctx)
+		var cancel context.CancelFunc
+		if deadline, ok := ctx.Deadline(); ok {
+			shareCtx, cancel = context.WithDeadline(shareCtx, deadline)
+		} else {
+			shareCtx, cancel = context.WithCancel(shareCtx)
+		}
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		c.flights[key] = f
+
+		go func() {
+			f.result, f.err = fn(shareCtx)
+			c.mu.Lock()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+			c.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	c.mu.Unlock()
+
+	leave := func() {
+		c.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+		}
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-f.done:
+		leave()
+	case <-ctx.Done():
+		leave()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return timeoutResult(op), nil
+		}
+		return nil, ctx.Err()
+	}
+
+	if !shared || f.result == nil {
+		return f.result, f.err
+	}
+	a.metrics.add("mcp_adapter_coalesced_total", 1, "tool", op.toolName)
+	result := *f.result
+	result.Meta = map[string]interface{}{"coalesced": true}
+	for k, v := range f.result.Meta {
+		result.Meta[k] = v
+	}
+	return &result, f.err
+}
@@ -0,0 +1,113 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+// waiting 返回正在等待合并请求的调用数
+func (c *coalesceConfig) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, f := range c.flights {
+		n += f.waiters
+	}
+	return n
+}
+
+func newBlockingBackend(calls *int32, unblock chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		<-unblock
+		w.Write([]byte("shared"))
+	}))
+}
+
+func TestCoalescing_SharesInflightCall(t *testing.T) {
+	var calls int32
+	unblock := make(chan struct{})
+	backend := newBlockingBackend(&calls, unblock)
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithRequestCoalescing())
+	var wg sync.WaitGroup
+	results := make([]*mcp.CallToolResult, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := adapter.server.WithContext(context.Background(), &fakeSession{id: string(rune('a' + i))})
+			result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+	assert.Eventually(t, func() bool { return adapter.coalescing.waiting() == 3 }, time.Second, time.Millisecond)
+	close(unblock)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	coalesced := 0
+	for _, result := range results {
+		assert.Equal(t, "shared", result.Content[0].(mcp.TextContent).Text)
+		if result.Meta["coalesced"] == true {
+			coalesced++
+		}
+	}
+	assert.Equal(t, 2, coalesced)
+	assert.Equal(t, 2.0, adapter.Metrics()[`mcp_adapter_coalesced_total{tool="_whoami_get"}`])
+}
+
+func TestCoalescing_LeaderCancelKeepsSharedCall(t *testing.T) {
+	var calls int32
+	unblock := make(chan struct{})
+	backend := newBlockingBackend(&calls, unblock)
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithRequestCoalescing("_whoami_get"))
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := adapter.handlers["_whoami_get"](leaderCtx, mcp.CallToolRequest{})
+		leaderErr <- err
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+
+	follower := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		follower <- result
+	}()
+	assert.Eventually(t, func() bool { return adapter.coalescing.waiting() == 2 }, time.Second, time.Millisecond)
+
+	cancelLeader()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	close(unblock)
+	assert.Equal(t, "shared", (<-follower).Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCoalescing_PerOperation(t *testing.T) {
+	adapter, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0", WithRequestCoalescing())
+	assert.NoError(t, err)
+	assert.True(t, adapter.operationCoalesce("_items_get", "get", nil))
+	assert.False(t, adapter.operationCoalesce("_items_post", "post", nil))
+	assert.False(t, adapter.operationCoalesce("_items_get", "get", map[string]interface{}{coalesceExtension: false}))
+
+	// 显式指定的工具也只合并安全方法
+	adapter, err = NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0", WithRequestCoalescing("_items_head", "_items_post"))
+	assert.NoError(t, err)
+	assert.False(t, adapter.operationCoalesce("_items_get", "get", nil))
+	assert.True(t, adapter.operationCoalesce("_items_head", "head", nil))
+	assert.False(t, adapter.operationCoalesce("_items_post", "post", nil))
+}
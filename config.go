@@ -0,0 +1,781 @@
+package gmadapter
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configSchema 是配置文件的 JSON Schema
+//
+//go:embed config.schema.json
+var configSchema []byte
+
+// ConfigSchema 返回配置文件的 JSON Schema，可供编辑器补全和校验，
+// 在 YAML 文件开头加上 "# yaml-language-server: $schema=<路径>" 即可启用
+func ConfigSchema() []byte {
+	return append([]byte(nil), configSchema...)
+}
+
+// Config 是适配器的声明式配置，可以从 YAML 或 JSON 文件加载，描述一个或多个 API
+type Config struct {
+	// Name 和 Version 是对 MCP 客户端报告的服务器信息，默认 go-mcp-adapter 和 1.0.0
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Listen 除 stdio 外的传输共用的监听地址，默认 :8081
+	Listen string `json:"listen,omitempty" yaml:"listen,omitempty"`
+	// Transports 启用的传输方式：sse、stdio、streamable-http、websocket，默认 sse
+	Transports []string `json:"transports,omitempty" yaml:"transports,omitempty"`
+	// BasePath 所有 MCP 端点的基础路径
+	BasePath string `json:"base_path,omitempty" yaml:"base_path,omitempty"`
+	// ShutdownGrace 关闭时等待进行中调用的时间，默认 30s
+	ShutdownGrace time.Duration `json:"shutdown_grace,omitempty" yaml:"shutdown_grace,omitempty"`
+	// Mode 多个 API 的提供方式：merged（默认）合并为一个 MCP 服务器，工具名靠 Prefix 区分；
+	// separate 每个 API 是独立的 MCP 服务器，端点位于 <base_path>/<API 名称> 之下
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// APIs 要提供的 API，至少一个
+	APIs []APIConfig `json:"apis" yaml:"apis"`
+
+	// unsetEnv 是 ParseConfig 展开时发现的未设置的环境变量
+	unsetEnv []string
+}
+
+// APIConfig 描述一个 OpenAPI 文档及其后端
+type APIConfig struct {
+	// Name API 的唯一名称，只能包含字母、数字、- 和 _
+	Name string `json:"name" yaml:"name"`
+	// Spec OpenAPI 文档的本地路径、file:// 或 http(s) URL，JSON 和 YAML 格式均可
+	Spec string `json:"spec" yaml:"spec"`
+	// Backend 后端地址，多实例时使用 Backends
+	Backend  string     `json:"backend,omitempty" yaml:"backend,omitempty"`
+	Backends []Endpoint `json:"backends,omitempty" yaml:"backends,omitempty"`
+	// LoadBalancer 多实例后端的负载均衡和健康检查
+	LoadBalancer *LoadBalancerConfig `json:"load_balancer,omitempty" yaml:"load_balancer,omitempty"`
+	// Prefix 加在生成的工具名之前
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// Auth 后端认证
+	Auth *AuthConfig `json:"auth,omitempty" yaml:"auth,omitempty"`
+	// Filter 选择生成工具的操作
+	Filter *FilterConfig `json:"filter,omitempty" yaml:"filter,omitempty"`
+	// Timeout 后端调用的默认超时时间
+	Timeout        time.Duration     `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	HTTPClient     *HTTPClientConfig `json:"http_client,omitempty" yaml:"http_client,omitempty"`
+	Retry          *RetryPolicy      `json:"retry,omitempty" yaml:"retry,omitempty"`
+	CircuitBreaker *BreakerConfig    `json:"circuit_breaker,omitempty" yaml:"circuit_breaker,omitempty"`
+	RateLimit      *RateLimitConfig  `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	WorkerPool     *WorkerPoolConfig `json:"worker_pool,omitempty" yaml:"worker_pool,omitempty"`
+	Cache          *CacheConfig      `json:"cache,omitempty" yaml:"cache,omitempty"`
+	// Operations 按生成的工具名（包含 Prefix）覆盖单个操作的配置
+	Operations map[string]OperationConfig `json:"operations,omitempty" yaml:"operations,omitempty"`
+	// Reload 监听 Spec 的变化并热加载工具
+	Reload *ReloadConfig `json:"reload,omitempty" yaml:"reload,omitempty"`
+	// SpecFetch 获取 http(s) 文档时使用的请求头、认证、TLS、超时和磁盘缓存
+	SpecFetch *SpecFetchConfig `json:"spec_fetch,omitempty" yaml:"spec_fetch,omitempty"`
+}
+
+// 多个 API 的提供方式
+const (
+	ModeMerged   = "merged"
+	ModeSeparate = "separate"
+)
+
+// 后端认证方式
+const (
+	AuthBearer        = "bearer"
+	AuthBasic         = "basic"
+	AuthAPIKey        = "api_key"
+	AuthTokenExchange = "token_exchange"
+)
+
+// AuthConfig 是后端认证配置，敏感字段可以写成 ${ENV_NAME} 从环境变量读取
+type AuthConfig struct {
+	// Type 认证方式：bearer、basic、api_key 或 token_exchange，只透传身份头时可以为空
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Token 是 bearer 认证的令牌
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	// Username 和 Password 用于 basic 认证
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	// Key 是 api_key 认证的值，写入 Header（默认 X-API-Key）或 Query 指定的查询参数
+	Key    string `json:"key,omitempty" yaml:"key,omitempty"`
+	Header string `json:"header,omitempty" yaml:"header,omitempty"`
+	Query  string `json:"query,omitempty" yaml:"query,omitempty"`
+	// TokenExchange 是 token_exchange 认证的配置
+	TokenExchange *TokenExchangeConfig `json:"token_exchange,omitempty" yaml:"token_exchange,omitempty"`
+	// Passthrough 透传给后端的调用方请求头
+	Passthrough []string `json:"passthrough,omitempty" yaml:"passthrough,omitempty"`
+}
+
+// FilterConfig 选择生成工具的操作：先按 Include 选择（为空时包含所有操作），再去掉匹配 Exclude 的操作
+type FilterConfig struct {
+	Include *OperationMatch `json:"include,omitempty" yaml:"include,omitempty"`
+	Exclude *OperationMatch `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// OperationMatch 匹配 OpenAPI 操作，不同字段之间需要同时满足，同一字段内满足任意一项即可，空字段不参与匹配
+type OperationMatch struct {
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Paths 是 path.Match 形式的路径模式，例如 /users/*
+	Paths        []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Methods      []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+	OperationIDs []string `json:"operation_ids,omitempty" yaml:"operation_ids,omitempty"`
+}
+
+// OperationConfig 覆盖单个操作的配置
+type OperationConfig struct {
+	// Disabled 不为该操作生成工具
+	Disabled  bool          `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Timeout   time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retry     *RetryPolicy  `json:"retry,omitempty" yaml:"retry,omitempty"`
+	RateLimit *RateLimit    `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	// CacheTTL 覆盖缓存时间，需要开启 Cache，负数表示不缓存
+	CacheTTL time.Duration `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty"`
+	// Coalesce 合并相同的并发调用，只适用于 GET、HEAD 和 OPTIONS 操作
+	Coalesce bool `json:"coalesce,omitempty" yaml:"coalesce,omitempty"`
+	// Priority 在工作池中的调度优先级，需要开启 WorkerPool
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// ConfigError 是配置校验发现的所有问题
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// envReference 匹配配置中的 ${ENV_NAME}
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadConfig 从 YAML 或 JSON 文件加载配置，不做校验。
+// 文件中的相对路径（OpenAPI 文档和证书）按配置文件所在目录解析。
+func LoadConfig(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	dir := filepath.Dir(file)
+	resolve := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) && !strings.Contains(*p, "://") {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	for i := range cfg.APIs {
+		api := &cfg.APIs[i]
+		resolve(&api.Spec)
+		if c := api.HTTPClient; c != nil {
+			resolve(&c.CAFile)
+			resolve(&c.CertFile)
+			resolve(&c.KeyFile)
+		}
+		if f := api.SpecFetch; f != nil {
+			resolve(&f.CacheDir)
+			if c := f.HTTPClient; c != nil {
+				resolve(&c.CAFile)
+				resolve(&c.CertFile)
+				resolve(&c.KeyFile)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// ParseConfig 解析 YAML 或 JSON 格式的配置，未知字段视为错误，不做校验。
+// 值中的 ${ENV_NAME} 在解析之后展开，环境变量的内容不会改变文档结构；未设置的环境变量由 Validate 报告。
+func ParseConfig(data []byte) (*Config, error) {
+	// JSON 是 YAML 的子集，统一按 YAML 解析
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	cfg := &Config{}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	if err := checkKnownFields(data); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	cfg.unsetEnv = expandEnv(doc.Content[0], "")
+	if err := doc.Content[0].Decode(cfg); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// checkKnownFields 严格解析展开前的配置，只报告未知字段；${ENV_NAME} 引起的类型错误留给展开后的解析
+func checkKnownFields(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var typeErr *yaml.TypeError
+	if !errors.As(dec.Decode(&Config{}), &typeErr) {
+		return nil
+	}
+	var unknown []string
+	for _, msg := range typeErr.Errors {
+		if strings.Contains(msg, " not found in type ") {
+			unknown = append(unknown, msg)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return &yaml.TypeError{Errors: unknown}
+}
+
+// expandEnv 展开标量值中的 ${ENV_NAME}，返回未设置的环境变量对应的问题，path 是节点在配置中的位置
+func expandEnv(node *yaml.Node, path string) []string {
+	var unset []string
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			field := node.Content[i].Value
+			if path != "" {
+				field = path + "." + field
+			}
+			unset = append(unset, expandEnv(node.Content[i+1], field)...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			unset = append(unset, expandEnv(item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case yaml.ScalarNode:
+		if !envReference.MatchString(node.Value) {
+			return nil
+		}
+		node.Value = envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				unset = append(unset, fmt.Sprintf("%s: environment variable %s is not set", path, name))
+			}
+			return value
+		})
+		// 没有引号和显式标签的值按展开后的内容推断类型，例如 weight: ${WEIGHT}
+		if node.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+	}
+	return unset
+}
+
+// configValidator 收集配置中的问题
+type configValidator struct {
+	problems []string
+}
+
+// check 在条件不满足时记录问题
+func (v *configValidator) check(ok bool, field, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// Validate 校验配置，返回的 *ConfigError 列出所有问题
+func (c *Config) Validate() error {
+	v := &configValidator{problems: append([]string(nil), c.unsetEnv...)}
+	for i, t := range c.Transports {
+		v.check(knownTransport(t), fmt.Sprintf("transports[%d]", i), "unknown transport %q", t)
+	}
+	v.check(c.ShutdownGrace >= 0, "shutdown_grace", "must not be negative")
+	switch c.Mode {
+	case "", ModeMerged:
+	case ModeSeparate:
+		for i, t := range c.Transports {
+			v.check(t != TransportStdio || len(c.APIs) < 2, fmt.Sprintf("transports[%d]", i), "stdio cannot serve several APIs in separate mode")
+		}
+	default:
+		v.check(false, "mode", "unknown mode %q, expected %s or %s", c.Mode, ModeMerged, ModeSeparate)
+	}
+	v.check(len(c.APIs) > 0, "apis", "at least one API is required")
+
+	names := make(map[string]int)
+	for i := range c.APIs {
+		field := fmt.Sprintf("apis[%d]", i)
+		api := &c.APIs[i]
+		api.validate(v, field)
+		if first, ok := names[api.Name]; ok && api.Name != "" {
+			v.check(false, field+".name", "duplicate API name %q, already used by apis[%d]", api.Name, first)
+		} else {
+			names[api.Name] = i
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return &ConfigError{Problems: v.problems}
+	}
+	return nil
+}
+
+var (
+	apiNamePattern    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	toolPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]*$`)
+)
+
+// validate 校验单个 API 的配置
+func (api *APIConfig) validate(v *configValidator, field string) {
+	v.check(api.Name != "", field+".name", "is required")
+	v.check(api.Name == "" || apiNamePattern.MatchString(api.Name), field+".name", "%q may only contain letters, digits, - and _", api.Name)
+	v.check(api.Spec != "", field+".spec", "is required")
+	if _, err := specLocation(api.Spec); err != nil {
+		v.check(false, field+".spec", "%v", err)
+	}
+	v.check(api.Backend != "" || len(api.Backends) > 0, field, "either backend or backends is required")
+	if api.Backend != "" {
+		validateURL(v, field+".backend", api.Backend)
+	}
+	for i, ep := range api.Backends {
+		validateURL(v, fmt.Sprintf("%s.backends[%d].url", field, i), ep.URL)
+		v.check(ep.Weight >= 0, fmt.Sprintf("%s.backends[%d].weight", field, i), "must not be negative")
+	}
+	v.check(toolPrefixPattern.MatchString(api.Prefix), field+".prefix", "%q may only contain letters, digits, -, _ and .", api.Prefix)
+	v.check(api.Timeout >= 0, field+".timeout", "must not be negative")
+
+	if lb := api.LoadBalancer; lb != nil {
+		switch lb.Strategy {
+		case "", RoundRobin, LeastInflight, Weighted:
+		default:
+			v.check(false, field+".load_balancer.strategy", "unknown strategy %q, expected %s, %s or %s", lb.Strategy, RoundRobin, LeastInflight, Weighted)
+		}
+	}
+	if api.Auth != nil {
+		api.Auth.validate(v, field+".auth")
+	}
+	if f := api.Filter; f != nil {
+		f.Include.validate(v, field+".filter.include")
+		f.Exclude.validate(v, field+".filter.exclude")
+	}
+	if api.Retry != nil {
+		validateRetry(v, field+".retry", api.Retry)
+	}
+	if rl := api.RateLimit; rl != nil {
+		validateRateLimit(v, field+".rate_limit.global", rl.Global)
+		validateRateLimit(v, field+".rate_limit.per_backend", rl.PerBackend)
+		validateRateLimit(v, field+".rate_limit.per_session", rl.PerSession)
+		validateRateLimit(v, field+".rate_limit.default_per_tool", rl.DefaultPerTool)
+		for name, limit := range rl.PerTool {
+			limit := limit
+			validateRateLimit(v, field+".rate_limit.per_tool."+name, &limit)
+		}
+	}
+
+	if r := api.Reload; r != nil {
+		v.check(r.Interval >= 0, field+".reload.interval", "must not be negative")
+		v.check(r.Debounce >= 0, field+".reload.debounce", "must not be negative")
+	}
+	if f := api.SpecFetch; f != nil {
+		location, _ := specLocation(api.Spec)
+		v.check(location.url != "", field+".spec_fetch", "only applies to http(s) specs")
+		v.check(f.Timeout >= 0, field+".spec_fetch.timeout", "must not be negative")
+		v.check(f.MaxBytes >= 0, field+".spec_fetch.max_bytes", "must not be negative")
+	}
+
+	for _, name := range sortedKeys(api.Operations) {
+		op := api.Operations[name]
+		opField := field + ".operations." + name
+		v.check(op.Timeout >= 0, opField+".timeout", "must not be negative")
+		v.check(op.CacheTTL == 0 || api.Cache != nil, opField+".cache_ttl", "requires cache to be enabled for the API")
+		v.check(op.Priority == 0 || api.WorkerPool != nil, opField+".priority", "requires worker_pool to be enabled for the API")
+		if op.Retry != nil {
+			validateRetry(v, opField+".retry", op.Retry)
+		}
+		validateRateLimit(v, opField+".rate_limit", op.RateLimit)
+	}
+}
+
+// validate 校验认证配置
+func (auth *AuthConfig) validate(v *configValidator, field string) {
+	switch auth.Type {
+	case "":
+		v.check(len(auth.Passthrough) > 0, field+".type", "is required unless passthrough headers are set")
+	case AuthBearer:
+		v.check(auth.Token != "", field+".token", "is required for bearer auth")
+	case AuthBasic:
+		v.check(auth.Username != "", field+".username", "is required for basic auth")
+	case AuthAPIKey:
+		v.check(auth.Key != "", field+".key", "is required for api_key auth")
+		v.check(auth.Header == "" || auth.Query == "", field, "set either header or query for api_key auth, not both")
+	case AuthTokenExchange:
+		v.check(auth.TokenExchange != nil && auth.TokenExchange.TokenURL != "", field+".token_exchange.token_url", "is required for token_exchange auth")
+		if auth.TokenExchange != nil && auth.TokenExchange.TokenURL != "" {
+			validateURL(v, field+".token_exchange.token_url", auth.TokenExchange.TokenURL)
+		}
+	default:
+		v.check(false, field+".type", "unknown auth type %q, expected %s, %s, %s or %s", auth.Type, AuthBearer, AuthBasic, AuthAPIKey, AuthTokenExchange)
+	}
+}
+
+// validate 校验操作匹配条件
+func (m *OperationMatch) validate(v *configValidator, field string) {
+	if m == nil {
+		return
+	}
+	for i, method := range m.Methods {
+		v.check(isHTTPMethod(method), fmt.Sprintf("%s.methods[%d]", field, i), "unknown HTTP method %q", method)
+	}
+	for i, pattern := range m.Paths {
+		_, err := path.Match(pattern, "")
+		v.check(err == nil, fmt.Sprintf("%s.paths[%d]", field, i), "invalid pattern %q", pattern)
+	}
+}
+
+// validateURL 校验后端地址是完整的 http(s) URL
+func validateURL(v *configValidator, field, raw string) {
+	u, err := url.Parse(raw)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "%q is not an absolute http(s) URL", raw)
+}
+
+// validateRetry 校验重试策略
+func validateRetry(v *configValidator, field string, p *RetryPolicy) {
+	v.check(p.MaxAttempts >= 0, field+".max_attempts", "must not be negative")
+	v.check(p.InitialBackoff >= 0 && p.MaxBackoff >= 0, field, "backoff must not be negative")
+	v.check(p.Jitter <= 1, field+".jitter", "must be at most 1")
+}
+
+// validateRateLimit 校验令牌桶参数
+func validateRateLimit(v *configValidator, field string, limit *RateLimit) {
+	if limit == nil {
+		return
+	}
+	v.check(limit.Rate > 0, field+".rate", "must be positive")
+	v.check(limit.Burst > 0, field+".burst", "must be positive")
+}
+
+// isHTTPMethod 判断是否是 OpenAPI 支持的 HTTP 方法
+func isHTTPMethod(method string) bool {
+	switch strings.ToLower(method) {
+	case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+		return true
+	}
+	return false
+}
+
+// sortedKeys 返回按字母排序的键
+func sortedKeys(m map[string]OperationConfig) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Options 把服务器级配置和一个 API 的配置转换为适配器选项
+func (c *Config) Options(api APIConfig) []Option {
+	return append(c.serverOptions(c.BasePath), api.options()...)
+}
+
+// serverOptions 把服务器级配置转换为适配器选项
+func (c *Config) serverOptions(basePath string) []Option {
+	opts := []Option{WithTransports(c.Transports...), WithBasePath(basePath)}
+	if c.ShutdownGrace > 0 {
+		opts = append(opts, WithShutdownGracePeriod(c.ShutdownGrace))
+	}
+	return opts
+}
+
+// options 把 API 的配置转换为适配器选项
+func (api APIConfig) options() []Option {
+	var opts []Option
+	if len(api.Backends) > 0 {
+		opts = append(opts, WithBackends(api.Backends...))
+	}
+	if api.LoadBalancer != nil {
+		opts = append(opts, WithLoadBalancer(*api.LoadBalancer))
+	}
+
+	naming := defaultToolName
+	if api.Prefix != "" {
+		naming = func(method, path string, operation map[string]interface{}) string {
+			return api.Prefix + defaultToolName(method, path, operation)
+		}
+		opts = append(opts, WithNaming(naming))
+	}
+	if filter := api.filter(naming); filter != nil {
+		opts = append(opts, WithFilter(filter))
+	}
+
+	if auth := api.Auth; auth != nil {
+		if provider := auth.provider(); provider != nil {
+			opts = append(opts, WithAuthProvider(provider))
+		}
+		// 令牌交换的主体令牌由提供者自行记录，不转发给后端
+		if len(auth.Passthrough) > 0 {
+			opts = append(opts, WithIdentityPassthrough(auth.Passthrough...))
+		}
+	}
+
+	if api.Timeout > 0 {
+		opts = append(opts, WithDefaultTimeout(api.Timeout))
+	}
+	if api.HTTPClient != nil {
+		opts = append(opts, WithHTTPClientConfig(*api.HTTPClient))
+	}
+	if api.Retry != nil {
+		opts = append(opts, WithRetryPolicy(*api.Retry))
+	}
+	if api.CircuitBreaker != nil {
+		opts = append(opts, WithCircuitBreaker(*api.CircuitBreaker))
+	}
+	if api.Reload != nil {
+		opts = append(opts, WithSpecReload(*api.Reload))
+	}
+	if api.SpecFetch != nil {
+		opts = append(opts, WithSpecFetch(*api.SpecFetch))
+	}
+
+	// 单个操作的覆盖合并到 API 级的配置中
+	var rateLimit RateLimitConfig
+	if api.RateLimit != nil {
+		rateLimit = *api.RateLimit
+	}
+	rateLimit.PerTool = copyMap(rateLimit.PerTool)
+	var pool WorkerPoolConfig
+	if api.WorkerPool != nil {
+		pool = *api.WorkerPool
+	}
+	pool.Priorities = copyMap(pool.Priorities)
+	var cache CacheConfig
+	if api.Cache != nil {
+		cache = *api.Cache
+	}
+	cache.OperationTTL = copyMap(cache.OperationTTL)
+
+	var coalesce []string
+	for _, name := range sortedKeys(api.Operations) {
+		op := api.Operations[name]
+		if op.Timeout > 0 {
+			opts = append(opts, WithOperationTimeout(name, op.Timeout))
+		}
+		if op.Retry != nil {
+			opts = append(opts, WithOperationRetryPolicy(name, *op.Retry))
+		}
+		if op.RateLimit != nil {
+			rateLimit.PerTool[name] = *op.RateLimit
+		}
+		if op.CacheTTL != 0 {
+			cache.OperationTTL[name] = op.CacheTTL
+		}
+		if op.Priority != 0 {
+			pool.Priorities[name] = op.Priority
+		}
+		if op.Coalesce {
+			coalesce = append(coalesce, name)
+		}
+	}
+	if api.RateLimit != nil || len(rateLimit.PerTool) > 0 {
+		opts = append(opts, WithRateLimit(rateLimit))
+	}
+	if api.WorkerPool != nil {
+		opts = append(opts, WithWorkerPool(NewWorkerPool(pool)))
+	}
+	if api.Cache != nil {
+		opts = append(opts, WithResponseCache(cache))
+	}
+	if len(coalesce) > 0 {
+		opts = append(opts, WithRequestCoalescing(coalesce...))
+	}
+	return opts
+}
+
+// copyMap 复制 map，nil 时返回空 map
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	out := make(map[K]V, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// filter 根据 Filter 和 Operations 中禁用的操作生成过滤函数，没有任何过滤条件时返回 nil
+func (api *APIConfig) filter(naming NamingFunc) FilterFunc {
+	disabled := false
+	for _, op := range api.Operations {
+		disabled = disabled || op.Disabled
+	}
+	if api.Filter == nil && !disabled {
+		return nil
+	}
+	return func(method, path string, operation map[string]interface{}) bool {
+		if f := api.Filter; f != nil {
+			if f.Include != nil && !f.Include.matches(method, path, operation) {
+				return false
+			}
+			if f.Exclude != nil && f.Exclude.matches(method, path, operation) {
+				return false
+			}
+		}
+		return !api.Operations[naming(method, path, operation)].Disabled
+	}
+}
+
+// matches 判断操作是否满足匹配条件
+func (m *OperationMatch) matches(method, p string, operation map[string]interface{}) bool {
+	if len(m.Methods) > 0 && !containsFold(m.Methods, method) {
+		return false
+	}
+	if len(m.Paths) > 0 {
+		matched := false
+		for _, pattern := range m.Paths {
+			if ok, _ := path.Match(pattern, p); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(m.OperationIDs) > 0 {
+		id, _ := operation["operationId"].(string)
+		if !containsFold(m.OperationIDs, id) {
+			return false
+		}
+	}
+	if len(m.Tags) > 0 {
+		tags, _ := operation["tags"].([]interface{})
+		matched := false
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok && containsFold(m.Tags, s) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold 判断列表中是否有忽略大小写后相等的项
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// provider 根据认证方式创建认证提供者，只透传身份头时返回 nil
+func (auth *AuthConfig) provider() AuthProvider {
+	switch auth.Type {
+	case AuthBearer:
+		return &StaticAuthProvider{Header: http.Header{"Authorization": {"Bearer " + auth.Token}}}
+	case AuthBasic:
+		credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		return &StaticAuthProvider{Header: http.Header{"Authorization": {"Basic " + credentials}}}
+	case AuthAPIKey:
+		if auth.Query != "" {
+			return &StaticAuthProvider{Query: url.Values{auth.Query: {auth.Key}}}
+		}
+		header := auth.Header
+		if header == "" {
+			header = "X-API-Key"
+		}
+		return &StaticAuthProvider{Header: http.Header{http.CanonicalHeaderKey(header): {auth.Key}}}
+	case AuthTokenExchange:
+		return NewTokenExchangeProvider(*auth.TokenExchange)
+	}
+	return nil
+}
+
+// NewFromConfig 校验配置，为每个 API 创建适配器并从 OpenAPI 文档生成工具。
+// 只有一个 API 时直接返回该 API 的适配器；有多个 API 时返回一个承载所有 API 的适配器，
+// 按 Mode 把各 API 的工具合并进来（Merge）或挂载在各自的路径下（Mount）。
+// opts 追加到每个适配器的选项之后，例如 WithLogger。
+func NewFromConfig(cfg *Config, opts ...Option) (*OpenAPIToMCPAdapter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	name, version, listen := cfg.Name, cfg.Version, cfg.Listen
+	if name == "" {
+		name = "go-mcp-adapter"
+	}
+	if version == "" {
+		version = "1.0.0"
+	}
+	if listen == "" {
+		listen = ":8081"
+	}
+	if len(cfg.APIs) == 1 {
+		return newAPIAdapter(name, version, listen, cfg.APIs[0], append(cfg.Options(cfg.APIs[0]), opts...))
+	}
+
+	host, err := NewOpenAPIToMCPAdapter(name, version, "", listen, append(cfg.serverOptions(cfg.BasePath), opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, api := range cfg.APIs {
+		if cfg.Mode == ModeSeparate {
+			memberOpts := append(cfg.serverOptions(cfg.BasePath+"/"+api.Name), api.options()...)
+			member, err := newAPIAdapter(api.Name, version, listen, api, append(memberOpts, opts...))
+			if err != nil {
+				return nil, err
+			}
+			if err := host.Mount(member); err != nil {
+				return nil, fmt.Errorf("api %s: %w", api.Name, err)
+			}
+			continue
+		}
+
+		member, err := newAPIAdapter(api.Name, version, listen, api, append(api.options(), opts...))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := host.Merge(member, ""); err != nil {
+			return nil, fmt.Errorf("api %s: %w", api.Name, err)
+		}
+	}
+	return host, nil
+}
+
+// newAPIAdapter 为一个 API 创建适配器并生成工具
+func newAPIAdapter(name, version, listen string, api APIConfig, opts []Option) (*OpenAPIToMCPAdapter, error) {
+	a, err := NewOpenAPIToMCPAdapter(name, version, api.Backend, listen, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("api %s: %w", api.Name, err)
+	}
+	if err := a.LoadOpenAPI(api.Spec); err != nil {
+		return nil, fmt.Errorf("api %s: load OpenAPI spec %s: %w", api.Name, api.Spec, err)
+	}
+	if err := a.GenerateTools(); err != nil {
+		return nil, fmt.Errorf("api %s: generate tools: %w", api.Name, err)
+	}
+	if err := api.checkCoalesce(a.toolMethods(a.openAPI)); err != nil {
+		return nil, fmt.Errorf("api %s: %w", api.Name, err)
+	}
+	return a, nil
+}
+
+// checkCoalesce 按文档中操作的实际方法校验 coalesce，合并写请求会丢失写入；methods 是工具名到 HTTP 方法的映射
+func (api APIConfig) checkCoalesce(methods map[string]string) error {
+	for _, name := range sortedKeys(api.Operations) {
+		method, ok := methods[name]
+		if api.Operations[name].Coalesce && ok && !isSafe(method) {
+			return fmt.Errorf("operations.%s.coalesce: only applies to GET, HEAD and OPTIONS operations, not %s", name, strings.ToUpper(method))
+		}
+	}
+	return nil
+}
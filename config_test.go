@@ -0,0 +1,311 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+const configTestSpec = `
+openapi: 3.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      tags: [users]
+      summary: List users
+    post:
+      operationId: createUser
+      tags: [users]
+      summary: Create user
+  /admin/reset:
+    post:
+      operationId: reset
+      tags: [admin]
+      summary: Reset everything
+`
+
+func TestParseConfig(t *testing.T) {
+	os.Setenv("CONFIG_TEST_TOKEN", "s3cret")
+	defer os.Unsetenv("CONFIG_TEST_TOKEN")
+
+	cfg, err := ParseConfig([]byte(`
+name: gateway
+transports: [sse, streamable-http]
+shutdown_grace: 10s
+apis:
+  - name: users
+    spec: ./users.yaml
+    backends:
+      - url: http://users-1:8080
+        weight: 2
+      - url: http://users-2:8080
+    load_balancer:
+      strategy: weighted
+    prefix: users
+    auth:
+      type: bearer
+      token: ${CONFIG_TEST_TOKEN}
+    filter:
+      include: {tags: [users]}
+    timeout: 5s
+    retry: {max_attempts: 3}
+    operations:
+      users_users_post:
+        timeout: 30s
+        rate_limit: {rate: 1, burst: 1}
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, []string{TransportSSE, TransportStreamableHTTP}, cfg.Transports)
+	assert.Equal(t, 10*time.Second, cfg.ShutdownGrace)
+
+	api := cfg.APIs[0]
+	assert.Equal(t, []Endpoint{{URL: "http://users-1:8080", Weight: 2}, {URL: "http://users-2:8080"}}, api.Backends)
+	assert.Equal(t, Weighted, api.LoadBalancer.Strategy)
+	assert.Equal(t, "s3cret", api.Auth.Token)
+	assert.Equal(t, []string{"users"}, api.Filter.Include.Tags)
+	assert.Equal(t, 3, api.Retry.MaxAttempts)
+	assert.Equal(t, 30*time.Second, api.Operations["users_users_post"].Timeout)
+
+	// JSON 同样可以解析
+	cfg, err = ParseConfig([]byte(`{"apis": [{"name": "a", "spec": "a.json", "backend": "http://a", "timeout": "1s"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, cfg.APIs[0].Timeout)
+}
+
+func TestLoadConfig_ResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "adapter.yaml")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(`
+apis:
+  - name: local
+    spec: specs/openapi.yaml
+    backend: http://local
+    http_client: {ca_file: ca.pem}
+  - name: remote
+    spec: https://example.com/openapi.json
+    backend: http://remote
+    spec_fetch:
+      cache_dir: cache
+      http_client: {cert_file: client.pem, key_file: /etc/client.key}
+`), 0644))
+
+	cfg, err := LoadConfig(file)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "specs/openapi.yaml"), cfg.APIs[0].Spec)
+	assert.Equal(t, filepath.Join(dir, "ca.pem"), cfg.APIs[0].HTTPClient.CAFile)
+	assert.Equal(t, "https://example.com/openapi.json", cfg.APIs[1].Spec)
+	assert.Equal(t, filepath.Join(dir, "cache"), cfg.APIs[1].SpecFetch.CacheDir)
+	assert.Equal(t, filepath.Join(dir, "client.pem"), cfg.APIs[1].SpecFetch.HTTPClient.CertFile)
+	assert.Equal(t, "/etc/client.key", cfg.APIs[1].SpecFetch.HTTPClient.KeyFile)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestParseConfig_EnvReferences(t *testing.T) {
+	os.Setenv("CONFIG_TEST_SECRET", "a: #b\n{c")
+	os.Setenv("CONFIG_TEST_WEIGHT", "3")
+	os.Setenv("CONFIG_TEST_TIMEOUT", "5s")
+	defer os.Unsetenv("CONFIG_TEST_SECRET")
+	defer os.Unsetenv("CONFIG_TEST_WEIGHT")
+	defer os.Unsetenv("CONFIG_TEST_TIMEOUT")
+
+	// 环境变量只替换值，不改变文档结构；注释中的引用不展开
+	cfg, err := ParseConfig([]byte(`
+apis:
+  - name: users # ${CONFIG_TEST_COMMENT}
+    spec: users.yaml
+    backends:
+      - url: http://users:8080
+        weight: ${CONFIG_TEST_WEIGHT}
+    timeout: ${CONFIG_TEST_TIMEOUT}
+    auth:
+      type: basic
+      username: "${CONFIG_TEST_SECRET}"
+      password: ${CONFIG_TEST_SECRET}-${CONFIG_TEST_TYPO}
+`))
+	assert.NoError(t, err)
+	api := cfg.APIs[0]
+	assert.Equal(t, "users", api.Name)
+	assert.Equal(t, 3, api.Backends[0].Weight)
+	assert.Equal(t, 5*time.Second, api.Timeout)
+	assert.Equal(t, "a: #b\n{c", api.Auth.Username)
+	assert.Equal(t, "a: #b\n{c-", api.Auth.Password)
+
+	var configErr *ConfigError
+	if assert.ErrorAs(t, cfg.Validate(), &configErr) {
+		assert.Equal(t, []string{"apis[0].auth.password: environment variable CONFIG_TEST_TYPO is not set"}, configErr.Problems)
+	}
+}
+
+func TestParseConfig_UnknownField(t *testing.T) {
+	_, err := ParseConfig([]byte("apis:\n  - name: a\n    spec: a.yaml\n    bakend: http://a\n"))
+	assert.ErrorContains(t, err, "line 4")
+	assert.ErrorContains(t, err, "bakend")
+}
+
+func TestConfigValidate_ReportsAllProblems(t *testing.T) {
+	cfg := &Config{
+		Transports: []string{"grpc"},
+		APIs: []APIConfig{
+			{Name: "users", Spec: "users.yaml", Backend: "users:8080"},
+			{
+				Name:       "users",
+				Backend:    "http://orders",
+				Auth:       &AuthConfig{Type: AuthBearer},
+				Filter:     &FilterConfig{Include: &OperationMatch{Methods: []string{"fetch"}, Paths: []string{"[/users"}}},
+				Operations: map[string]OperationConfig{"get": {CacheTTL: time.Minute, RateLimit: &RateLimit{Rate: 1}}},
+			},
+			{Name: "bad name", Spec: "x.yaml", Backends: []Endpoint{{URL: "http://x"}}, LoadBalancer: &LoadBalancerConfig{Strategy: "random"}},
+		},
+	}
+	err := cfg.Validate()
+	var configErr *ConfigError
+	if !assert.ErrorAs(t, err, &configErr) {
+		return
+	}
+	assert.Equal(t, []string{
+		`transports[0]: unknown transport "grpc"`,
+		`apis[0].backend: "users:8080" is not an absolute http(s) URL`,
+		`apis[1].spec: is required`,
+		`apis[1].auth.token: is required for bearer auth`,
+		`apis[1].filter.include.methods[0]: unknown HTTP method "fetch"`,
+		`apis[1].filter.include.paths[0]: invalid pattern "[/users"`,
+		`apis[1].operations.get.cache_ttl: requires cache to be enabled for the API`,
+		`apis[1].operations.get.rate_limit.burst: must be positive`,
+		`apis[1].name: duplicate API name "users", already used by apis[0]`,
+		`apis[2].name: "bad name" may only contain letters, digits, - and _`,
+		`apis[2].load_balancer.strategy: unknown strategy "random", expected round-robin, least-inflight or weighted`,
+	}, configErr.Problems)
+
+	assert.EqualError(t, (&Config{}).Validate(), "invalid config:\n  apis: at least one API is required")
+}
+
+func TestNewFromConfig(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Api-Key")))
+	}))
+	defer backend.Close()
+
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "openapi.yaml")
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(configTestSpec), 0644))
+
+	cfg := &Config{APIs: []APIConfig{{
+		Name:    "users",
+		Spec:    spec,
+		Backend: backend.URL,
+		Prefix:  "users",
+		Auth:    &AuthConfig{Type: AuthAPIKey, Key: "k1"},
+		Filter:  &FilterConfig{Exclude: &OperationMatch{Tags: []string{"admin"}}},
+		Operations: map[string]OperationConfig{
+			"users_users_post": {Disabled: true},
+		},
+	}}}
+	adapter, err := NewFromConfig(cfg)
+	assert.NoError(t, err)
+
+	var names []string
+	for name := range adapter.tools {
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"users_users_get"}, names)
+
+	result, err := adapter.handlers["users_users_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "GET /users k1", result.Content[0].(mcp.TextContent).Text)
+
+	// coalesce 按文档中操作的实际方法校验，与工具名无关
+	cfg.APIs[0].Operations = map[string]OperationConfig{"users_users_post": {Coalesce: true}}
+	_, err = NewFromConfig(cfg)
+	assert.ErrorContains(t, err, "api users: operations.users_users_post.coalesce: only applies to GET, HEAD and OPTIONS operations, not POST")
+	cfg.APIs[0].Operations = map[string]OperationConfig{"users_users_get": {Coalesce: true}, "users_users_post": {Disabled: true}}
+	_, err = NewFromConfig(cfg)
+	assert.NoError(t, err)
+
+	// 合并模式下工具名冲突
+	cfg.APIs = append(cfg.APIs, APIConfig{Name: "orders", Spec: spec, Backend: backend.URL, Prefix: "users"})
+	_, err = NewFromConfig(cfg)
+	assert.ErrorContains(t, err, "api orders: tool users_users_get is already registered")
+
+	// 额外的选项作用于承载适配器和每个 API 的适配器
+	cfg.APIs[1].Prefix = "orders"
+	adapter, err = NewFromConfig(cfg, WithLogger(zerolog.Nop()))
+	assert.NoError(t, err)
+	assert.NotNil(t, adapter.logger)
+	for _, member := range adapter.children() {
+		assert.NotNil(t, member.logger)
+	}
+}
+
+// TestConfigSchema_CoversConfig 确保 JSON Schema 与配置结构保持一致
+func TestConfigSchema_CoversConfig(t *testing.T) {
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(ConfigSchema(), &schema))
+	definitions := schema["definitions"].(map[string]interface{})
+
+	resolve := func(node map[string]interface{}) map[string]interface{} {
+		for {
+			ref, ok := node["$ref"].(string)
+			if !ok {
+				return node
+			}
+			node = definitions[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{})
+		}
+	}
+
+	var walk func(typ reflect.Type, node map[string]interface{}, field string)
+	walk = func(typ reflect.Type, node map[string]interface{}, field string) {
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+				return
+			}
+			node = resolve(node)
+			switch typ.Kind() {
+			case reflect.Slice:
+				node = node["items"].(map[string]interface{})
+			case reflect.Map:
+				node, _ = node["additionalProperties"].(map[string]interface{})
+			}
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		node = resolve(node)
+		properties, _ := node["properties"].(map[string]interface{})
+		var seen []string
+		for i := 0; i < typ.NumField(); i++ {
+			name := strings.Split(typ.Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "-" || name == "" {
+				continue
+			}
+			seen = append(seen, name)
+			property, ok := properties[name].(map[string]interface{})
+			if assert.True(t, ok, "schema is missing %s.%s", field, name) {
+				walk(typ.Field(i).Type, property, field+"."+name)
+			}
+		}
+		var documented []string
+		for name := range properties {
+			documented = append(documented, name)
+		}
+		sort.Strings(seen)
+		sort.Strings(documented)
+		assert.Equal(t, seen, documented, "schema properties of %s", field)
+	}
+	walk(reflect.TypeOf(Config{}), schema, "config")
+}
@@ -0,0 +1,226 @@
+package gmadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Discoverer 从服务注册中心发现后端实例
+type Discoverer interface {
+	// Watch 持续发现后端实例，实例集合变化时调用 update，直到 ctx 结束。
+	// 适配器通过 zerolog.Ctx(ctx) 传入 WithLogger 设置的日志记录器
+	Watch(ctx context.Context, update func([]Endpoint)) error
+}
+
+// WithDiscovery 通过服务发现动态维护后端实例
+func WithDiscovery(d Discoverer) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.discoverer = d
+	}
+}
+
+// DNSSRVDiscoverer 通过 DNS SRV 记录发现后端实例
+type DNSSRVDiscoverer struct {
+	// Service、Proto 和 Name 组成查询的记录，例如 _http._tcp.orders.svc.cluster.local
+	Service string
+	Proto   string
+	Name    string
+	// Scheme 拼接实例地址使用的协议，默认 http
+	Scheme string
+	// Interval 重新解析的间隔，默认 30s
+	Interval time.Duration
+	// Resolver 使用的解析器，默认 net.DefaultResolver
+	Resolver *net.Resolver
+}
+
+// Lookup 解析一次 SRV 记录
+func (d *DNSSRVDiscoverer) Lookup(ctx context.Context) ([]Endpoint, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	scheme := d.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, d.Service, d.Proto, d.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只使用优先级最高（数值最小）的一组记录
+	var endpoints []Endpoint
+	for _, srv := range records {
+		if srv.Priority != records[0].Priority {
+			continue
+		}
+		host := strings.TrimSuffix(srv.Target, ".")
+		endpoints = append(endpoints, Endpoint{
+			URL:    fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(int(srv.Port)))),
+			Weight: int(srv.Weight),
+		})
+	}
+	return endpoints, nil
+}
+
+// Watch 实现 Discoverer 接口
+func (d *DNSSRVDiscoverer) Watch(ctx context.Context, update func([]Endpoint)) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return pollEndpoints(ctx, interval, d.Lookup, update)
+}
+
+// FileDiscoverer 从 JSON 或 YAML 文件读取后端实例，文件变化时自动重新加载。
+// 文件内容可以是实例列表，也可以是包含 endpoints 字段的对象。
+type FileDiscoverer struct {
+	Path string
+	// Debounce 收到文件变化通知后等待的时间，合并一次保存产生的多个事件，默认 500ms
+	Debounce time.Duration
+	// Interval 文件系统通知不可用时检查文件变化的间隔，默认 5s
+	Interval time.Duration
+}
+
+// registryFile 是注册文件的对象形式
+type registryFile struct {
+	Endpoints []Endpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// Lookup 读取一次注册文件
+func (d *FileDiscoverer) Lookup(ctx context.Context) ([]Endpoint, error) {
+	data, err := ioutil.ReadFile(d.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("parse registry file %s: %w", d.Path, err)
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	var endpoints []Endpoint
+	if node.Content[0].Kind == yaml.SequenceNode {
+		err = node.Content[0].Decode(&endpoints)
+	} else {
+		var file registryFile
+		err = node.Content[0].Decode(&file)
+		endpoints = file.Endpoints
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse registry file %s: %w", d.Path, err)
+	}
+	return endpoints, nil
+}
+
+// Watch 实现 Discoverer 接口，通过文件系统通知监听文件变化，通知不可用时按间隔检查修改时间和大小
+func (d *FileDiscoverer) Watch(ctx context.Context, update func([]Endpoint)) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	debounce := d.Debounce
+	if debounce <= 0 {
+		debounce = 500 * time.Millisecond
+	}
+
+	logger := contextLogger(ctx)
+	watcher, err := newFileWatcher(d.Path)
+	if err == nil {
+		defer watcher.Close()
+		apply := endpointApplier(logger, update)
+		apply(d.Lookup(ctx))
+		watchFileEvents(ctx, watcher, d.Path, debounce, func(err error) {
+			logger.Printf("watch registry file %s: %v", d.Path, err)
+		}, func() {
+			apply(d.Lookup(ctx))
+		})
+		return ctx.Err()
+	}
+	logger.Printf("watch registry file %s: %v; polling every %s instead", d.Path, err, interval)
+
+	var lastMod time.Time
+	var lastSize int64 = -1
+	lookup := func(ctx context.Context) ([]Endpoint, error) {
+		info, err := os.Stat(d.Path)
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+			return nil, errUnchanged
+		}
+		endpoints, err := d.Lookup(ctx)
+		if err == nil {
+			lastMod, lastSize = info.ModTime(), info.Size()
+		}
+		return endpoints, err
+	}
+	return pollEndpoints(ctx, interval, lookup, update)
+}
+
+// contextLogger 返回 ctx 中的日志记录器，没有时使用全局的日志记录器
+func contextLogger(ctx context.Context) *zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger != zerolog.Ctx(context.Background()) {
+		return logger
+	}
+	return &log.Logger
+}
+
+// errUnchanged 表示实例来源没有变化，无需更新
+var errUnchanged = errors.New("unchanged")
+
+// pollEndpoints 定期调用 lookup，实例集合变化时调用 update
+func pollEndpoints(ctx context.Context, interval time.Duration, lookup func(context.Context) ([]Endpoint, error), update func([]Endpoint)) error {
+	apply := endpointApplier(contextLogger(ctx), update)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		apply(lookup(ctx))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// endpointApplier 返回处理一次查询结果的函数，实例集合变化时调用 update；查询失败或没有查到任何实例时保留上一次的结果，
+// 避免 SRV 记录短暂为空或注册文件写到一半时摘除所有后端
+func endpointApplier(logger *zerolog.Logger, update func([]Endpoint)) func([]Endpoint, error) {
+	var last []Endpoint
+	first := true
+	return func(endpoints []Endpoint, err error) {
+		switch {
+		case err == errUnchanged:
+		case err != nil:
+			logger.Printf("discover backends: %v", err)
+		case len(endpoints) == 0:
+			logger.Printf("discover backends: no endpoints found, keeping the previous %d", len(last))
+		default:
+			sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].URL < endpoints[j].URL })
+			if first || !reflect.DeepEqual(endpoints, last) {
+				logger.Printf("discovered %d backend endpoints", len(endpoints))
+				update(endpoints)
+				last, first = endpoints, false
+			}
+		}
+	}
+}
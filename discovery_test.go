@@ -0,0 +1,98 @@
+package gmadapter
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDiscoverer_Formats(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "backends.json")
+	assert.NoError(t, ioutil.WriteFile(jsonPath, []byte(`[{"url":"http://a:8080","weight":2}]`), 0644))
+	endpoints, err := (&FileDiscoverer{Path: jsonPath}).Lookup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Endpoint{{URL: "http://a:8080", Weight: 2}}, endpoints)
+
+	yamlPath := filepath.Join(dir, "backends.yaml")
+	assert.NoError(t, ioutil.WriteFile(yamlPath, []byte("endpoints:\n  - url: http://b:8080\n  - url: http://c:8080\n"), 0644))
+	endpoints, err = (&FileDiscoverer{Path: yamlPath}).Lookup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Endpoint{{URL: "http://b:8080"}, {URL: "http://c:8080"}}, endpoints)
+
+	assert.NoError(t, ioutil.WriteFile(yamlPath, []byte("endpoints: [\n"), 0644))
+	_, err = (&FileDiscoverer{Path: yamlPath}).Lookup(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFileDiscoverer_WatchUpdatesBackends(t *testing.T) {
+	b1 := newNamedBackend("b1")
+	defer b1.Close()
+	b2 := newNamedBackend("b2")
+	defer b2.Close()
+
+	path := filepath.Join(t.TempDir(), "backends.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("- url: "+b1.URL+"\n"), 0644))
+
+	adapter := newIdentityTestAdapter(t, "", WithDiscovery(&FileDiscoverer{Path: path, Debounce: 10 * time.Millisecond}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	adapter.StartBackground(ctx)
+
+	call := func() string {
+		result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	assert.Eventually(t, func() bool { return len(adapter.backends.urls()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "b1", call())
+
+	// 写入无效内容时保留上一次的实例
+	assert.NoError(t, ioutil.WriteFile(path, []byte("- url: [\n"), 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{b1.URL}, adapter.backends.urls())
+
+	// 文件被清空时同样保留上一次的实例
+	assert.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{b1.URL}, adapter.backends.urls())
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("- url: "+b2.URL+"\n"), 0644))
+	assert.Eventually(t, func() bool {
+		urls := adapter.backends.urls()
+		return len(urls) == 1 && urls[0] == b2.URL
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "b2", call())
+}
+
+func TestPollEndpoints_KeepsLastEndpoints(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	ctx, cancel := context.WithCancel(logger.WithContext(context.Background()))
+	defer cancel()
+
+	results := [][]Endpoint{{{URL: "http://b1"}}, nil, {}}
+	var updates [][]Endpoint
+	lookup := func(ctx context.Context) ([]Endpoint, error) {
+		if len(results) == 0 {
+			cancel()
+			return nil, errUnchanged
+		}
+		endpoints := results[0]
+		results = results[1:]
+		return endpoints, nil
+	}
+	assert.ErrorIs(t, pollEndpoints(ctx, time.Millisecond, lookup, func(endpoints []Endpoint) {
+		updates = append(updates, endpoints)
+	}), context.Canceled)
+	assert.Equal(t, [][]Endpoint{{{URL: "http://b1"}}}, updates)
+	assert.Contains(t, buf.String(), "no endpoints found, keeping the previous 1")
+}
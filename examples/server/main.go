@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
@@ -20,12 +21,15 @@ type Config struct {
 	AdapterAddress string `json:"adapter_address" yaml:"adapter_address"`
 }
 
+// 在仓库根目录运行：go run ./examples/server -spec examples/server/openapi.yaml。
+// 不需要内置的示例后端时，也可以用 go-mcp-adapter serve --config examples/adapter.yaml 直接提供服务。
 func main() {
 	config := Config{
-		OpenAPI:        `D:\code\src\github.com\go-mcp-adapter\examples\server\openapi.yaml`,
-		ServerBaseUrl:  "http://localhost:8080",
-		AdapterAddress: "0.0.0.0:8081",
+		ServerBaseUrl: "http://localhost:8080",
 	}
+	flag.StringVar(&config.OpenAPI, "spec", "examples/server/openapi.yaml", "path or URL of the OpenAPI spec")
+	flag.StringVar(&config.AdapterAddress, "listen", "0.0.0.0:8081", "address the MCP adapter listens on")
+	flag.Parse()
 
 	// backend servers
 	go OpenAPIServer(config.ServerBaseUrl)
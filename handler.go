@@ -0,0 +1,73 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithBasePath 设置 HTTP 端点的基础路径，例如 /api/mcp。
+// SSE 端点为 <base>/sse 和 <base>/message，streamable HTTP 为 <base>/mcp，WebSocket 为 <base>/ws。
+func WithBasePath(path string) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		path = strings.TrimSuffix(path, "/")
+		if path != "" && !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		a.basePath = path
+	}
+}
+
+// Handler 返回提供 HTTP 类传输的 http.Handler，可以挂载到 net/http、gin 等任意路由中。
+// 挂载时请保留完整路径（不要剥离前缀），并用 WithBasePath 设置相同的基础路径。
+// 首次调用时把生成的工具注册到 MCP 服务器，并包含 Mount 挂载的适配器的端点；后端健康检查和服务发现需要另外调用 StartBackground。
+func (a *OpenAPIToMCPAdapter) Handler() http.Handler {
+	a.handlerOnce.Do(func() {
+		a.registerTools()
+
+		transports, err := a.enabledTransports()
+		if err != nil {
+			a.log().Printf("build mcp handler: %v", err)
+		}
+		mux := http.NewServeMux()
+		for _, t := range transports {
+			switch t {
+			case TransportSSE:
+				// WithSSEOptions 中的 server.WithBasePath 在后面执行，覆盖适配器的基础路径
+				opts := append([]server.SSEOption{server.WithBasePath(a.basePath)}, a.sseOptions...)
+				opts = append(opts,
+					// SSE 服务器只在 Shutdown 时关闭会话，并要求设置了 http.Server；监听由调用方负责
+					server.WithHTTPServer(&http.Server{}),
+					server.WithSSEContextFunc(a.sseContextFunc),
+				)
+				s := server.NewSSEServer(a.server, opts...)
+				sse := a.identityMiddleware(s)
+				mux.Handle(s.CompleteSsePath(), sse)
+				mux.Handle(s.CompleteMessagePath(), sse)
+				a.httpShutdown = append(a.httpShutdown, s.Shutdown)
+			case TransportStreamableHTTP:
+				s := newStreamableServer(a)
+				mux.Handle(a.basePath+streamablePath, s)
+				a.httpShutdown = append(a.httpShutdown, s.shutdown)
+			case TransportWebSocket:
+				s := newWebSocketServer(a)
+				mux.Handle(a.basePath+websocketPath, s)
+				a.httpShutdown = append(a.httpShutdown, s.shutdown)
+			}
+		}
+		for _, m := range a.mounted() {
+			mux.Handle(m.basePath+"/", m.Handler())
+		}
+		a.handler = mux
+	})
+	return a.handler
+}
+
+// shutdownHandler 关闭 Handler 中各传输的会话
+func (a *OpenAPIToMCPAdapter) shutdownHandler(ctx context.Context) {
+	for _, fn := range a.httpShutdown {
+		fn(ctx)
+	}
+}
@@ -0,0 +1,81 @@
+package gmadapter
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_MountedUnderBasePath(t *testing.T) {
+	adapter := newIdentityTestAdapter(t, "http://backend",
+		WithBasePath("/api/mcp/"),
+		WithTransports(TransportSSE, TransportStreamableHTTP),
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/other", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("other route"))
+	})
+	mux.Handle("/api/mcp/", adapter.Handler())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/other")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "other route", string(body))
+
+	// SSE 端点在基础路径下，并返回带基础路径的消息端点
+	stream, err := http.Get(srv.URL + "/api/mcp/sse")
+	assert.NoError(t, err)
+	defer stream.Body.Close()
+	events := bufio.NewScanner(stream.Body)
+	for events.Scan() && !strings.HasPrefix(events.Text(), "data: ") {
+	}
+	endpoint := strings.TrimPrefix(events.Text(), "data: ")
+	assert.True(t, strings.HasPrefix(endpoint, "/api/mcp/message?sessionId="), endpoint)
+
+	resp, err = http.Post(srv.URL+endpoint, "application/json", strings.NewReader(initializeMessage))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+"/api/mcp/mcp", "application/json", strings.NewReader(initializeMessage))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(sessionIDHeader))
+
+	resp, err = http.Get(srv.URL + "/api/mcp/unknown")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.Same(t, adapter.Handler(), adapter.Handler())
+}
+
+func TestHandler_SSEBasePathOption(t *testing.T) {
+	adapter := newIdentityTestAdapter(t, "http://backend",
+		WithBasePath("/api/mcp"),
+		WithSSEOptions(server.WithBasePath("/legacy")),
+		WithTransports(TransportSSE),
+	)
+	srv := httptest.NewServer(adapter.Handler())
+	defer srv.Close()
+
+	stream, err := http.Get(srv.URL + "/legacy/sse")
+	assert.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Equal(t, http.StatusOK, stream.StatusCode)
+	events := bufio.NewScanner(stream.Body)
+	for events.Scan() && !strings.HasPrefix(events.Text(), "data: ") {
+	}
+	assert.True(t, strings.HasPrefix(events.Text(), "data: /legacy/message?sessionId="), events.Text())
+}
@@ -0,0 +1,167 @@
+package gmadapter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPClientConfig 是访问后端使用的 HTTP 客户端配置，零值字段使用默认值
+type HTTPClientConfig struct {
+	// ConnectTimeout 建立 TCP 连接的超时时间
+	ConnectTimeout time.Duration `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"`
+	// TLSHandshakeTimeout TLS 握手超时时间
+	TLSHandshakeTimeout time.Duration `json:"tls_handshake_timeout,omitempty" yaml:"tls_handshake_timeout,omitempty"`
+	// ResponseHeaderTimeout 等待后端响应头的超时时间
+	ResponseHeaderTimeout time.Duration `json:"response_header_timeout,omitempty" yaml:"response_header_timeout,omitempty"`
+	// Timeout 单次请求的总超时时间，0 表示不限制
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// KeepAlive TCP keep-alive 间隔
+	KeepAlive time.Duration `json:"keep_alive,omitempty" yaml:"keep_alive,omitempty"`
+	// IdleConnTimeout 空闲连接的保留时间
+	IdleConnTimeout time.Duration `json:"idle_conn_timeout,omitempty" yaml:"idle_conn_timeout,omitempty"`
+	// MaxIdleConns 连接池中空闲连接的总数上限
+	MaxIdleConns int `json:"max_idle_conns,omitempty" yaml:"max_idle_conns,omitempty"`
+	// MaxIdleConnsPerHost 每个后端主机的空闲连接上限
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty" yaml:"max_idle_conns_per_host,omitempty"`
+	// MaxConnsPerHost 每个后端主机的连接总数上限，0 表示不限制
+	MaxConnsPerHost int `json:"max_conns_per_host,omitempty" yaml:"max_conns_per_host,omitempty"`
+	// ProxyURL 使用的 HTTP 代理，为空时读取 HTTP_PROXY 等环境变量
+	ProxyURL string `json:"proxy_url,omitempty" yaml:"proxy_url,omitempty"`
+	// CAFile 额外信任的 CA 证书文件（PEM）
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	// CAPEM 额外信任的 CA 证书内容（PEM）
+	CAPEM []byte `json:"ca_pem,omitempty" yaml:"ca_pem,omitempty"`
+	// CertFile 和 KeyFile 是 mTLS 使用的客户端证书
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	// InsecureSkipVerify 跳过后端证书校验，仅用于开发环境
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// defaultHTTPClient 是未配置客户端时使用的共享客户端
+var defaultHTTPClient, _ = NewHTTPClient(HTTPClientConfig{})
+
+// WithHTTPClient 使用调用方提供的 HTTP 客户端访问后端
+func WithHTTPClient(c *http.Client) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.httpClient = c
+	}
+}
+
+// WithHTTPClientConfig 按配置创建访问后端的 HTTP 客户端
+func WithHTTPClientConfig(cfg HTTPClientConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.httpClientConfig = &cfg
+	}
+}
+
+// NewHTTPClient 根据配置创建 HTTP 客户端
+func NewHTTPClient(cfg HTTPClientConfig) (*http.Client, error) {
+	if cfg.ConnectTimeout == 0 {
+		cfg.ConnectTimeout = 10 * time.Second
+	}
+	if cfg.TLSHandshakeTimeout == 0 {
+		cfg.TLSHandshakeTimeout = 10 * time.Second
+	}
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = 30 * time.Second
+	}
+	if cfg.IdleConnTimeout == 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = 100
+	}
+	if cfg.MaxIdleConnsPerHost == 0 {
+		cfg.MaxIdleConnsPerHost = 16
+	}
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %q: %w", cfg.ProxyURL, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.ConnectTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}, nil
+}
+
+// tlsConfig 根据配置构建 TLS 设置
+func (cfg HTTPClientConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" || len(cfg.CAPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if cfg.CAFile != "" {
+			pem, err := ioutil.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read ca file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in ca file %s", cfg.CAFile)
+			}
+		}
+		if len(cfg.CAPEM) > 0 && !pool.AppendCertsFromPEM(cfg.CAPEM) {
+			return nil, errors.New("no certificates found in ca pem")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// client 返回访问后端使用的 HTTP 客户端
+func (a *OpenAPIToMCPAdapter) client() *http.Client {
+	if a.httpClient != nil {
+		return a.httpClient
+	}
+	return defaultHTTPClient
+}
@@ -0,0 +1,49 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPClient_CustomCA(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	_, err := defaultHTTPClient.Get(backend.URL)
+	assert.Error(t, err)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: backend.Certificate().Raw})
+	adapter := newIdentityTestAdapter(t, backend.URL, WithHTTPClientConfig(HTTPClientConfig{CAPEM: caPEM}))
+
+	result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestNewHTTPClient_InvalidConfig(t *testing.T) {
+	_, err := NewHTTPClient(HTTPClientConfig{CAPEM: []byte("not a certificate")})
+	assert.Error(t, err)
+
+	_, err = NewHTTPClient(HTTPClientConfig{ProxyURL: "://bad"})
+	assert.Error(t, err)
+
+	_, err = NewOpenAPIToMCPAdapter("test", "1.0.0", "http://localhost", "localhost:0",
+		WithHTTPClientConfig(HTTPClientConfig{CertFile: "missing.crt", KeyFile: "missing.key"}))
+	assert.Error(t, err)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	client := &http.Client{}
+	adapter, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://localhost", "localhost:0",
+		WithHTTPClient(client), WithHTTPClientConfig(HTTPClientConfig{InsecureSkipVerify: true}))
+	assert.NoError(t, err)
+	assert.Same(t, client, adapter.client())
+}
@@ -0,0 +1,177 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// IdentityTransformer 在转发到后端之前对会话身份头进行变换，返回的头会覆盖到后端请求上
+type IdentityTransformer func(ctx context.Context, identity http.Header) (http.Header, error)
+
+// identityKey 是上下文中保存调用方身份头的键
+type identityKey struct{}
+
+// sessionHolderKey 是 SSE 连接上下文中用于回填会话 ID 的键
+type sessionHolderKey struct{}
+
+// sessionHolder 在 SSE 连接建立时记录 mcp-go 生成的会话 ID，便于连接结束后清理
+type sessionHolder struct {
+	sessionID string
+}
+
+// WithIdentityPassthrough 开启调用方身份透传，默认透传 Authorization 头
+func WithIdentityPassthrough(headers ...string) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		if len(headers) == 0 {
+			headers = []string{"Authorization"}
+		}
+		a.identityHeaders = headers
+	}
+}
+
+// WithIdentityTransformer 设置身份头的变换函数，例如把用户 token 换成后端可识别的凭证
+func WithIdentityTransformer(fn IdentityTransformer) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.identityTransformer = fn
+	}
+}
+
+// IdentityFromContext 返回当前工具调用所属会话的身份头
+func IdentityFromContext(ctx context.Context) http.Header {
+	identity, _ := ctx.Value(identityKey{}).(http.Header)
+	return identity
+}
+
+// identitySignature 把调用上下文中的身份头按名字排序拼接，用于区分不同身份的请求
+func identitySignature(ctx context.Context) string {
+	identity := IdentityFromContext(ctx)
+	names := make([]string, 0, len(identity))
+	for name := range identity {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString("\n" + name + ": " + strings.Join(identity[name], ","))
+	}
+	return b.String()
+}
+
+// captureIdentity 从请求中提取需要透传的身份头
+func (a *OpenAPIToMCPAdapter) captureIdentity(r *http.Request) http.Header {
+	identity := http.Header{}
+	for _, name := range a.capturedHeaders() {
+		if values := r.Header.Values(name); len(values) > 0 {
+			identity[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return identity
+}
+
+// identityMiddleware 在 SSE 连接上记录身份头和会话，会话注册时按会话 ID 保存身份头，连接断开后清理；
+// 关闭过程中拒绝建立新的 SSE 连接
+func (a *OpenAPIToMCPAdapter) identityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && a.rejectNewSession(w) {
+			return
+		}
+		holder := &sessionHolder{}
+		ctx := r.Context()
+		if len(a.capturedHeaders()) > 0 {
+			ctx = context.WithValue(ctx, identityKey{}, a.captureIdentity(r))
+		}
+		ctx = context.WithValue(ctx, sessionHolderKey{}, holder)
+		next.ServeHTTP(w, r.WithContext(ctx))
+		if holder.sessionID != "" {
+			a.forgetSession(holder.sessionID)
+		}
+	})
+}
+
+// onRegisterSession 在 SSE 会话建立时保存该会话的身份头
+func (a *OpenAPIToMCPAdapter) onRegisterSession(ctx context.Context, session server.ClientSession) {
+	if holder, ok := ctx.Value(sessionHolderKey{}).(*sessionHolder); ok {
+		holder.sessionID = session.SessionID()
+	}
+	a.sessions.Store(session.SessionID(), session)
+	if identity := IdentityFromContext(ctx); len(identity) > 0 {
+		a.identities.Store(session.SessionID(), identity)
+	}
+}
+
+// sseContextFunc 为每条消息合并会话级身份头和消息请求自身携带的身份头，再执行 WithContextFunc 设置的自定义函数
+func (a *OpenAPIToMCPAdapter) sseContextFunc(ctx context.Context, r *http.Request) context.Context {
+	if len(a.capturedHeaders()) > 0 {
+		identity := http.Header{}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			if stored, ok := a.identities.Load(session.SessionID()); ok {
+				for k, v := range stored.(http.Header) {
+					identity[k] = v
+				}
+			}
+		}
+		for k, v := range a.captureIdentity(r) {
+			identity[k] = v
+		}
+		ctx = context.WithValue(ctx, identityKey{}, identity)
+	}
+	if a.contextFunc != nil {
+		ctx = a.contextFunc(ctx, r)
+	}
+	return ctx
+}
+
+// capturedHeaders 返回需要从调用方请求中记录的身份头，包括 Merge 合并的适配器需要的身份头
+func (a *OpenAPIToMCPAdapter) capturedHeaders() []string {
+	headers := a.sessionHeaders()
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	if len(a.mergedHeaders) == 0 {
+		return headers
+	}
+	return append(append([]string{}, headers...), a.mergedHeaders...)
+}
+
+// sessionHeaders 返回当前适配器自身需要的身份头：透传的头和认证提供者读取的头
+func (a *OpenAPIToMCPAdapter) sessionHeaders() []string {
+	reader, ok := a.authProvider.(subjectReader)
+	if !ok {
+		return a.identityHeaders
+	}
+	headers := a.identityHeaders
+	for _, name := range reader.subjectHeaders() {
+		if !containsFold(headers, name) {
+			headers = append(append([]string{}, headers...), name)
+		}
+	}
+	return headers
+}
+
+// applyIdentity 把会话身份头写入后端请求，只写入 WithIdentityPassthrough 声明的头，认证提供者读取的头不会转发
+func (a *OpenAPIToMCPAdapter) applyIdentity(ctx context.Context, req *http.Request) error {
+	identity := http.Header{}
+	for k, v := range IdentityFromContext(ctx) {
+		if containsFold(a.identityHeaders, k) {
+			identity[k] = v
+		}
+	}
+	if a.identityTransformer != nil {
+		transformed, err := a.identityTransformer(ctx, identity.Clone())
+		if err != nil {
+			return err
+		}
+		identity = transformed
+	}
+	for k, v := range identity {
+		req.Header.Del(k)
+		for _, value := range v {
+			req.Header.Add(k, value)
+		}
+	}
+	return nil
+}
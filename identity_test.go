@@ -0,0 +1,86 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSession struct {
+	id string
+}
+
+func (s *fakeSession) Initialize()                                         {}
+func (s *fakeSession) Initialized() bool                                   { return true }
+func (s *fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *fakeSession) SessionID() string                                   { return s.id }
+
+func newIdentityTestAdapter(t *testing.T, backendURL string, opts ...Option) *OpenAPIToMCPAdapter {
+	adapter, err := NewOpenAPIToMCPAdapter("test", "1.0.0", backendURL, "localhost:0", opts...)
+	assert.NoError(t, err)
+	adapter.openAPI = map[string]interface{}{
+		"paths": map[string]interface{}{
+			"/whoami": map[string]interface{}{
+				"get": map[string]interface{}{"summary": "Who am I"},
+			},
+		},
+	}
+	assert.NoError(t, adapter.GenerateTools())
+	return adapter
+}
+
+func TestIdentityPassthrough_MessageHeader(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithIdentityPassthrough())
+
+	r := httptest.NewRequest(http.MethodPost, "/message?sessionId=s1", nil)
+	r.Header.Set("Authorization", "Bearer alice")
+	ctx := adapter.sseContextFunc(adapter.server.WithContext(context.Background(), &fakeSession{id: "s1"}), r)
+
+	result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer alice", result.Content[0].(mcp.TextContent).Text)
+}
+
+func TestIdentityPassthrough_SessionHeaderAndTransform(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-User")))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL,
+		WithIdentityPassthrough("X-Token"),
+		WithIdentityTransformer(func(ctx context.Context, identity http.Header) (http.Header, error) {
+			return http.Header{"X-User": []string{"user-of-" + identity.Get("X-Token")}}, nil
+		}),
+	)
+
+	session := &fakeSession{id: "s2"}
+	var registered bool
+	sse := adapter.identityMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adapter.onRegisterSession(r.Context(), session)
+		_, registered = adapter.identities.Load(session.id)
+
+		msg := httptest.NewRequest(http.MethodPost, "/message?sessionId=s2", nil)
+		ctx := adapter.sseContextFunc(adapter.server.WithContext(context.Background(), session), msg)
+		result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, "user-of-bob", result.Content[0].(mcp.TextContent).Text)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/sse", nil)
+	r.Header.Set("X-Token", "bob")
+	sse.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.True(t, registered)
+	_, stillStored := adapter.identities.Load(session.id)
+	assert.False(t, stillStored)
+}
@@ -0,0 +1,184 @@
+package gmadapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsRegistry 是适配器内部的简单指标注册表，按 Prometheus 文本格式输出
+type metricsRegistry struct {
+	mu       sync.Mutex
+	counters map[string]float64
+	gauges   []func(emit func(name string, value float64, labels ...string))
+}
+
+// newMetricsRegistry 创建指标注册表
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{counters: make(map[string]float64)}
+}
+
+// metricName 把指标名和成对的标签拼成 Prometheus 样式的序列名
+func metricName(name string, labels ...string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", labels[i], labels[i+1])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// add 累加计数器
+func (m *metricsRegistry) add(name string, value float64, labels ...string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.counters[metricName(name, labels...)] += value
+	m.mu.Unlock()
+}
+
+// observe 记录一次耗时，输出 _sum 和 _count 两个序列
+func (m *metricsRegistry) observe(name string, d time.Duration, labels ...string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.counters[metricName(name+"_seconds_sum", labels...)] += d.Seconds()
+	m.counters[metricName(name+"_seconds_count", labels...)]++
+	m.mu.Unlock()
+}
+
+// gauge 注册一个在抓取时计算的指标
+func (m *metricsRegistry) gauge(fn func(emit func(name string, value float64, labels ...string))) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.gauges = append(m.gauges, fn)
+	m.mu.Unlock()
+}
+
+// Snapshot 返回所有指标的当前值
+func (m *metricsRegistry) Snapshot() map[string]float64 {
+	snapshot := make(map[string]float64)
+	if m == nil {
+		return snapshot
+	}
+
+	m.mu.Lock()
+	for k, v := range m.counters {
+		snapshot[k] = v
+	}
+	gauges := append([]func(emit func(string, float64, ...string)){}, m.gauges...)
+	m.mu.Unlock()
+
+	for _, fn := range gauges {
+		fn(func(name string, value float64, labels ...string) {
+			snapshot[metricName(name, labels...)] = value
+		})
+	}
+	return snapshot
+}
+
+// ServeHTTP 以 Prometheus 文本格式输出指标
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := m.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %v\n", name, snapshot[name])
+	}
+}
+
+// Metrics 返回适配器当前的指标快照
+func (a *OpenAPIToMCPAdapter) Metrics() map[string]float64 {
+	return a.metrics.Snapshot()
+}
+
+// MetricsHandler 返回以 Prometheus 文本格式输出指标的处理器
+func (a *OpenAPIToMCPAdapter) MetricsHandler() http.Handler {
+	return a.metrics
+}
+
+// HealthStatus 是健康检查的输出
+type HealthStatus struct {
+	Status    string                   `json:"status"`
+	Breakers  map[string]BreakerState  `json:"breakers,omitempty"`
+	Endpoints map[string]EndpointState `json:"endpoints,omitempty"`
+}
+
+// Health 返回适配器的健康状态，任一断路器打开或没有可用后端实例时状态为 degraded，
+// 合并和挂载的适配器的状态一并计入
+func (a *OpenAPIToMCPAdapter) Health() HealthStatus {
+	health := HealthStatus{
+		Status:    "ok",
+		Breakers:  a.breakerStates(),
+		Endpoints: a.endpointStates(),
+	}
+	for _, child := range a.children() {
+		h := child.Health()
+		if h.Status != "ok" {
+			health.Status = "degraded"
+		}
+		for name, state := range h.Breakers {
+			if health.Breakers == nil {
+				health.Breakers = make(map[string]BreakerState)
+			}
+			health.Breakers[name] = state
+		}
+		for url, state := range h.Endpoints {
+			if health.Endpoints == nil {
+				health.Endpoints = make(map[string]EndpointState)
+			}
+			health.Endpoints[url] = state
+		}
+	}
+	for _, state := range health.Breakers {
+		if state.State != BreakerClosed {
+			health.Status = "degraded"
+		}
+	}
+	if len(health.Endpoints) > 0 {
+		available := false
+		for _, state := range health.Endpoints {
+			available = available || (state.Healthy && !state.Ejected)
+		}
+		if !available {
+			health.Status = "degraded"
+		}
+	}
+	if a.draining.Load() {
+		health.Status = "draining"
+	}
+	return health
+}
+
+// HealthHandler 返回以 JSON 输出健康状态的处理器，关闭过程中返回 503 以便负载均衡摘除流量
+func (a *OpenAPIToMCPAdapter) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := a.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if health.Status == "draining" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(health)
+	})
+}
@@ -0,0 +1,182 @@
+package gmadapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Merge 把另一个适配器生成的工具合并到当前适配器，通过当前适配器的传输提供，prefix 非空时加在工具名前面，返回合并的工具名。
+// 合并的工具仍按 other 自己的后端、认证、重试和限流配置调用后端；当前适配器负责会话、取消和优雅关闭，
+// 并额外透传 other 需要的身份头，每个工具只会收到 other 声明的身份头。工具名冲突时不合并任何工具。
+// other 开启 WithSpecReload 时，文档的改动会同步到当前适配器。other 的指标带上 api 标签输出到当前适配器，
+// 标签值为去掉末尾 _ 的 prefix，prefix 为空时使用 other 的名称。
+func (a *OpenAPIToMCPAdapter) Merge(other *OpenAPIToMCPAdapter, prefix string) ([]string, error) {
+	if other == a {
+		return nil, fmt.Errorf("cannot merge an adapter into itself")
+	}
+
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	other.toolsMu.RLock()
+	defer other.toolsMu.RUnlock()
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+
+	names := other.enabledTools()
+	for _, toolName := range names {
+		if _, ok := a.tools[prefix+toolName]; ok {
+			return nil, fmt.Errorf("tool %s is already registered; use a different prefix", prefix+toolName)
+		}
+	}
+
+	if a.mergedTools == nil {
+		a.mergedTools = make(map[string]*OpenAPIToMCPAdapter)
+	}
+	tools := make([]server.ServerTool, 0, len(names))
+	for i, toolName := range names {
+		tool := *other.tools[toolName]
+		tool.Name = prefix + toolName
+		handler := a.mergedHandler(other, tool.Name, other.toolHandler(toolName))
+		a.tools[tool.Name] = &tool
+		a.handlers[tool.Name] = handler
+		a.mergedTools[tool.Name] = other
+		tools = append(tools, server.ServerTool{Tool: tool, Handler: handler})
+		names[i] = tool.Name
+	}
+	for _, name := range other.sessionHeaders() {
+		if !containsFold(a.sessionHeaders(), name) && !containsFold(a.mergedHeaders, name) {
+			a.mergedHeaders = append(a.mergedHeaders, name)
+		}
+	}
+	a.members = append(a.members, other)
+	if a.memberPrefixes == nil {
+		a.memberPrefixes = make(map[*OpenAPIToMCPAdapter]string)
+	}
+	a.memberPrefixes[other] = prefix
+	other.addHost(a)
+	label := strings.TrimSuffix(prefix, "_")
+	if label == "" {
+		label = other.name
+	}
+	a.metrics.gauge(other.metricsGauge(label))
+	a.server.AddTools(tools...)
+	return names, nil
+}
+
+// mergedHandler 包装合并进来的工具：在当前适配器上登记调用以支持取消和优雅关闭，other 不再重复登记；并只保留 other 需要的身份头
+func (a *OpenAPIToMCPAdapter) mergedHandler(other *OpenAPIToMCPAdapter, toolName string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := a.trackCall(ctx, toolName)
+		defer cancel()
+		if a.draining.Load() {
+			return mcp.NewToolResultError("the MCP adapter is shutting down; try again later"), nil
+		}
+
+		headers := other.sessionHeaders()
+		identity := http.Header{}
+		for k, v := range IdentityFromContext(ctx) {
+			if containsFold(headers, k) {
+				identity[k] = v
+			}
+		}
+		ctx = context.WithValue(ctx, identityKey{}, identity)
+		return handler(ctx, request)
+	}
+}
+
+// Mount 让另一个适配器作为独立的 MCP 服务器在当前适配器的进程和监听地址上提供服务，端点位于 other 的 WithBasePath 之下。
+// 需要在 Start 或 Handler 之前调用；other 的后台任务和关闭由当前适配器一并管理，指标带上值为 other 名称的 api 标签。
+func (a *OpenAPIToMCPAdapter) Mount(other *OpenAPIToMCPAdapter) error {
+	if other == a {
+		return fmt.Errorf("cannot mount an adapter into itself")
+	}
+	if other.basePath == "" || other.basePath == a.basePath {
+		return fmt.Errorf("a mounted adapter needs its own base path, set it with WithBasePath")
+	}
+	transports, err := other.enabledTransports()
+	if err != nil {
+		return err
+	}
+	for _, t := range transports {
+		if t == TransportStdio {
+			return fmt.Errorf("a mounted adapter cannot use the stdio transport")
+		}
+	}
+
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	for _, m := range a.mounts {
+		if m.basePath == other.basePath {
+			return fmt.Errorf("base path %s is already mounted", other.basePath)
+		}
+	}
+	a.mounts = append(a.mounts, other)
+	a.metrics.gauge(other.metricsGauge(other.name))
+	return nil
+}
+
+// addHost 记录合并了当前适配器的适配器，热加载时同步改动
+func (a *OpenAPIToMCPAdapter) addHost(host *OpenAPIToMCPAdapter) {
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	a.hosts = append(a.hosts, host)
+}
+
+// children 返回合并和挂载到当前适配器的适配器
+func (a *OpenAPIToMCPAdapter) children() []*OpenAPIToMCPAdapter {
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	return append(append([]*OpenAPIToMCPAdapter{}, a.members...), a.mounts...)
+}
+
+// mounted 返回挂载到当前适配器的适配器
+func (a *OpenAPIToMCPAdapter) mounted() []*OpenAPIToMCPAdapter {
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	return append([]*OpenAPIToMCPAdapter{}, a.mounts...)
+}
+
+// metricsGauge 把适配器的全部指标加上 api 标签后输出到另一个注册表中，避免多个适配器的同名序列互相覆盖
+func (a *OpenAPIToMCPAdapter) metricsGauge(api string) func(emit func(name string, value float64, labels ...string)) {
+	label := fmt.Sprintf("api=%q", api)
+	return func(emit func(name string, value float64, labels ...string)) {
+		for series, value := range a.metrics.Snapshot() {
+			i := strings.IndexByte(series, '{')
+			switch {
+			case i < 0:
+				series += "{" + label + "}"
+			case !strings.HasPrefix(series[i+1:], "api="):
+				// 多层合并时保留最内层的 api 标签
+				series = series[:i+1] + label + "," + series[i+1:]
+			}
+			emit(series, value)
+		}
+	}
+}
+
+// shutdownMounted 并行关闭挂载的适配器，返回合并后的摘要
+func (a *OpenAPIToMCPAdapter) shutdownMounted(ctx context.Context) ShutdownSummary {
+	var mu sync.Mutex
+	var summary ShutdownSummary
+	var wg sync.WaitGroup
+	for _, m := range a.mounted() {
+		wg.Add(1)
+		go func(m *OpenAPIToMCPAdapter) {
+			defer wg.Done()
+			s, _ := m.Shutdown(ctx)
+			mu.Lock()
+			summary.Sessions += s.Sessions
+			summary.Drained += s.Drained
+			summary.Aborted = append(summary.Aborted, s.Aborted...)
+			mu.Unlock()
+		}(m)
+	}
+	wg.Wait()
+	return summary
+}
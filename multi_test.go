@@ -0,0 +1,178 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+// newEchoBackend 返回把指定请求头写回响应的后端
+func newEchoBackend(name string, headers ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := name
+		for _, h := range headers {
+			reply += " " + h + "=" + r.Header.Get(h)
+		}
+		w.Write([]byte(reply))
+	}))
+}
+
+func TestMerge(t *testing.T) {
+	users := newEchoBackend("users", "Authorization", "X-Tenant")
+	defer users.Close()
+	orders := newEchoBackend("orders", "Authorization", "X-Tenant")
+	defer orders.Close()
+
+	host, err := NewOpenAPIToMCPAdapter("gateway", "1.0.0", "", "localhost:0")
+	assert.NoError(t, err)
+	usersAdapter := newIdentityTestAdapter(t, users.URL, WithIdentityPassthrough("Authorization"))
+	ordersAdapter := newIdentityTestAdapter(t, orders.URL, WithIdentityPassthrough("X-Tenant"))
+
+	names, err := host.Merge(usersAdapter, "users")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users_whoami_get"}, names)
+	names, err = host.Merge(ordersAdapter, "orders")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orders_whoami_get"}, names)
+
+	_, err = host.Merge(ordersAdapter, "orders")
+	assert.ErrorContains(t, err, "orders_whoami_get is already registered")
+
+	// 每个 API 只收到自己声明的身份头
+	r := httptest.NewRequest(http.MethodPost, "/message?sessionId=s1", nil)
+	r.Header.Set("Authorization", "Bearer alice")
+	r.Header.Set("X-Tenant", "acme")
+	ctx := host.sseContextFunc(host.server.WithContext(context.Background(), &fakeSession{id: "s1"}), r)
+
+	result, err := host.handlers["users_whoami_get"](ctx, mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "users Authorization=Bearer alice X-Tenant=", result.Content[0].(mcp.TextContent).Text)
+	result, err = host.handlers["orders_whoami_get"](ctx, mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "orders Authorization= X-Tenant=acme", result.Content[0].(mcp.TextContent).Text)
+
+	response := host.server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, _ := json.Marshal(response)
+	assert.Contains(t, string(data), "users_whoami_get")
+	assert.Contains(t, string(data), "orders_whoami_get")
+
+	// 合并工具的后端指标在宿主适配器上可见，按 API 区分
+	usersAdapter.metrics.add("mcp_adapter_spec_reloads_total", 1)
+	ordersAdapter.metrics.add("mcp_adapter_spec_reloads_total", 2)
+	metrics := host.Metrics()
+	assert.Equal(t, 1.0, metrics[`mcp_adapter_spec_reloads_total{api="users"}`])
+	assert.Equal(t, 2.0, metrics[`mcp_adapter_spec_reloads_total{api="orders"}`])
+	assert.Equal(t, 1.0, metrics[`mcp_adapter_backend_requests_total{api="users",tool="_whoami_get",status="200"}`])
+	assert.Equal(t, 1.0, metrics[`mcp_adapter_backend_requests_total{api="orders",tool="_whoami_get",status="200"}`])
+	assert.NotContains(t, metrics, `mcp_adapter_backend_requests_total{tool="_whoami_get",status="200"}`)
+}
+
+func TestMerge_TracksCallOnce(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer backend.Close()
+
+	host, err := NewOpenAPIToMCPAdapter("gateway", "1.0.0", "", "localhost:0")
+	assert.NoError(t, err)
+	member := newSlowTestAdapter(t, backend.URL, map[string]interface{}{"summary": "slow"})
+	_, err = host.Merge(member, "shop")
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		host.handlers["shop_slow_get"](context.Background(), mcp.CallToolRequest{})
+	}()
+
+	// 合并工具的调用只登记在宿主上，关闭宿主时不会和 other 重复计数
+	assert.Eventually(t, func() bool {
+		host.callsMu.Lock()
+		defer host.callsMu.Unlock()
+		return len(host.calls) == 1
+	}, time.Second, 5*time.Millisecond)
+	member.callsMu.Lock()
+	assert.Empty(t, member.calls)
+	member.callsMu.Unlock()
+
+	close(release)
+	<-done
+}
+
+func TestNewFromConfig_SeparateMode(t *testing.T) {
+	users := newEchoBackend("users")
+	defer users.Close()
+	orders := newEchoBackend("orders")
+	defer orders.Close()
+
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "openapi.yaml")
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(configTestSpec), 0644))
+
+	host, err := NewFromConfig(&Config{
+		Mode:       ModeSeparate,
+		BasePath:   "/mcp",
+		Transports: []string{TransportStreamableHTTP},
+		APIs: []APIConfig{
+			{Name: "users", Spec: spec, Backend: users.URL, Filter: &FilterConfig{Include: &OperationMatch{Tags: []string{"users"}}}},
+			{Name: "orders", Spec: spec, Backend: orders.URL, Filter: &FilterConfig{Include: &OperationMatch{Tags: []string{"admin"}}}},
+		},
+	})
+	assert.NoError(t, err)
+	srv := httptest.NewServer(host.Handler())
+	defer srv.Close()
+
+	call := func(path, body string, sessionID string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(sessionIDHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp, string(data)
+	}
+
+	for path, want := range map[string]string{"/mcp/users/mcp": "_users_post", "/mcp/orders/mcp": "_admin_reset_post"} {
+		resp, _ := call(path, initializeMessage, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		sessionID := resp.Header.Get(sessionIDHeader)
+		_, body := call(path, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, sessionID)
+		assert.Contains(t, body, want, path)
+	}
+	_, body := call("/mcp/orders/mcp", initializeMessage, "")
+	assert.Contains(t, body, `"name":"orders"`)
+
+	summary, err := host.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.Drained)
+	resp, _ := call("/mcp/users/mcp", initializeMessage, "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestMount_Errors(t *testing.T) {
+	host, err := NewOpenAPIToMCPAdapter("gateway", "1.0.0", "", "localhost:0")
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, host.Mount(newIdentityTestAdapter(t, "http://a")), "needs its own base path")
+	assert.ErrorContains(t, host.Mount(newIdentityTestAdapter(t, "http://a", WithBasePath("/a"), WithTransports(TransportStdio))), "stdio")
+	assert.NoError(t, host.Mount(newIdentityTestAdapter(t, "http://a", WithBasePath("/a"))))
+	assert.ErrorContains(t, host.Mount(newIdentityTestAdapter(t, "http://b", WithBasePath("/a"))), "already mounted")
+
+	assert.ErrorContains(t, (&Config{Mode: ModeSeparate, Transports: []string{TransportStdio}, APIs: []APIConfig{
+		{Name: "a", Spec: "a.yaml", Backend: "http://a"},
+		{Name: "b", Spec: "b.yaml", Backend: "http://b"},
+	}}).Validate(), "stdio cannot serve several APIs in separate mode")
+}
@@ -0,0 +1,151 @@
+package gmadapter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// NamingFunc 根据 OpenAPI 操作生成工具名
+type NamingFunc func(method, path string, operation map[string]interface{}) string
+
+// FilterFunc 决定 OpenAPI 操作是否生成工具，返回 false 时跳过
+type FilterFunc func(method, path string, operation map[string]interface{}) bool
+
+// WithServerOptions 追加创建 MCP 服务器时使用的选项，例如 server.WithRecovery、server.WithInstructions。
+// 适配器依赖自己的钩子，server.WithHooks 会被覆盖，自定义钩子请使用 WithHooks。
+func WithServerOptions(opts ...server.ServerOption) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.serverOptions = append(a.serverOptions, opts...)
+	}
+}
+
+// WithHooks 把自定义钩子合并到适配器的钩子中，只复制调用时已注册的钩子
+func WithHooks(hooks *server.Hooks) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		addHooks(a.hooks, hooks)
+	}
+}
+
+// addHooks 通过 server.Hooks 的注册方法把 src 中的钩子逐类追加到 dst
+func addHooks(dst, src *server.Hooks) {
+	for _, hook := range src.OnRegisterSession {
+		dst.AddOnRegisterSession(hook)
+	}
+	for _, hook := range src.OnBeforeAny {
+		dst.AddBeforeAny(hook)
+	}
+	for _, hook := range src.OnSuccess {
+		dst.AddOnSuccess(hook)
+	}
+	for _, hook := range src.OnError {
+		dst.AddOnError(hook)
+	}
+	for _, hook := range src.OnBeforeInitialize {
+		dst.AddBeforeInitialize(hook)
+	}
+	for _, hook := range src.OnAfterInitialize {
+		dst.AddAfterInitialize(hook)
+	}
+	for _, hook := range src.OnBeforePing {
+		dst.AddBeforePing(hook)
+	}
+	for _, hook := range src.OnAfterPing {
+		dst.AddAfterPing(hook)
+	}
+	for _, hook := range src.OnBeforeListResources {
+		dst.AddBeforeListResources(hook)
+	}
+	for _, hook := range src.OnAfterListResources {
+		dst.AddAfterListResources(hook)
+	}
+	for _, hook := range src.OnBeforeListResourceTemplates {
+		dst.AddBeforeListResourceTemplates(hook)
+	}
+	for _, hook := range src.OnAfterListResourceTemplates {
+		dst.AddAfterListResourceTemplates(hook)
+	}
+	for _, hook := range src.OnBeforeReadResource {
+		dst.AddBeforeReadResource(hook)
+	}
+	for _, hook := range src.OnAfterReadResource {
+		dst.AddAfterReadResource(hook)
+	}
+	for _, hook := range src.OnBeforeListPrompts {
+		dst.AddBeforeListPrompts(hook)
+	}
+	for _, hook := range src.OnAfterListPrompts {
+		dst.AddAfterListPrompts(hook)
+	}
+	for _, hook := range src.OnBeforeGetPrompt {
+		dst.AddBeforeGetPrompt(hook)
+	}
+	for _, hook := range src.OnAfterGetPrompt {
+		dst.AddAfterGetPrompt(hook)
+	}
+	for _, hook := range src.OnBeforeListTools {
+		dst.AddBeforeListTools(hook)
+	}
+	for _, hook := range src.OnAfterListTools {
+		dst.AddAfterListTools(hook)
+	}
+	for _, hook := range src.OnBeforeCallTool {
+		dst.AddBeforeCallTool(hook)
+	}
+	for _, hook := range src.OnAfterCallTool {
+		dst.AddAfterCallTool(hook)
+	}
+}
+
+// WithSSEOptions 追加 SSE 传输的选项，例如 server.WithBasePath、server.WithKeepAlive。
+// server.WithBasePath 优先于 WithBasePath，但只影响 SSE 端点，其他传输仍使用 WithBasePath 设置的路径。
+// server.WithHTTPServer 和 server.WithSSEContextFunc 由适配器设置，自定义上下文请使用 WithContextFunc。
+func WithSSEOptions(opts ...server.SSEOption) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.sseOptions = append(a.sseOptions, opts...)
+	}
+}
+
+// WithContextFunc 为 HTTP 类传输的每条消息定制上下文，在身份透传之后执行
+func WithContextFunc(fn server.SSEContextFunc) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.contextFunc = fn
+	}
+}
+
+// WithLogger 设置适配器使用的日志，默认使用 zerolog 的全局日志
+func WithLogger(logger zerolog.Logger) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.logger = &logger
+	}
+}
+
+// WithNaming 自定义工具名，默认把路径中的 / 替换为 _ 后拼接方法名，例如 _users_get
+func WithNaming(fn NamingFunc) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.naming = fn
+	}
+}
+
+// WithFilter 只为 fn 返回 true 的操作生成工具
+func WithFilter(fn FilterFunc) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.filter = fn
+	}
+}
+
+// defaultToolName 是默认的工具命名规则
+func defaultToolName(method, path string, _ map[string]interface{}) string {
+	return fmt.Sprintf("%s_%s", strings.ReplaceAll(path, "/", "_"), method)
+}
+
+// log 返回适配器使用的日志
+func (a *OpenAPIToMCPAdapter) log() *zerolog.Logger {
+	if a.logger != nil {
+		return a.logger
+	}
+	return &log.Logger
+}
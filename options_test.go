@@ -0,0 +1,92 @@
+package gmadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions_NamingAndFilter(t *testing.T) {
+	var logs bytes.Buffer
+	adapter, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0",
+		WithLogger(zerolog.New(&logs)),
+		WithNaming(func(method, path string, operation map[string]interface{}) string {
+			if id, ok := operation["operationId"].(string); ok {
+				return id
+			}
+			return method + strings.ReplaceAll(path, "/", ".")
+		}),
+		WithFilter(func(method, path string, operation map[string]interface{}) bool {
+			return method == "get"
+		}),
+	)
+	assert.NoError(t, err)
+	adapter.openAPI = map[string]interface{}{
+		"paths": map[string]interface{}{
+			"/users": map[string]interface{}{
+				"get":  map[string]interface{}{"operationId": "listUsers"},
+				"post": map[string]interface{}{"operationId": "createUser"},
+			},
+			"/users/{id}": map[string]interface{}{
+				"get": map[string]interface{}{},
+			},
+		},
+	}
+	assert.NoError(t, adapter.GenerateTools())
+
+	var names []string
+	for name := range adapter.tools {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{"listUsers", "get.users.{id}"}, names)
+	assert.Contains(t, logs.String(), "create a tool for listUsers")
+}
+
+func TestOptions_ServerOptionsHooksAndContext(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	type tenantKey struct{}
+	var called []string
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest) {
+		called = append(called, message.Params.Name+":"+ctx.Value(tenantKey{}).(string))
+	})
+
+	adapter := newIdentityTestAdapter(t, backend.URL,
+		WithServerOptions(server.WithInstructions("use the tools"), server.WithHooks(&server.Hooks{})),
+		WithHooks(hooks),
+		WithContextFunc(func(ctx context.Context, r *http.Request) context.Context {
+			return context.WithValue(ctx, tenantKey{}, r.Header.Get("X-Tenant"))
+		}),
+	)
+	adapter.registerTools()
+
+	r := httptest.NewRequest(http.MethodPost, "/message", nil)
+	r.Header.Set("X-Tenant", "acme")
+	ctx := adapter.sseContextFunc(context.Background(), r)
+
+	var initialize struct {
+		Result mcp.InitializeResult `json:"result"`
+	}
+	data, _ := json.Marshal(adapter.server.HandleMessage(ctx, []byte(initializeMessage)))
+	assert.NoError(t, json.Unmarshal(data, &initialize))
+	assert.Equal(t, "use the tools", initialize.Result.Instructions)
+
+	response := adapter.server.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"_whoami_get","arguments":{}}}`))
+	data, _ = json.Marshal(response)
+	assert.Contains(t, string(data), `"text":"ok"`)
+	// server.WithHooks 不会替换适配器的钩子，自定义钩子合并后生效
+	assert.Equal(t, []string{"_whoami_get:acme"}, called)
+}
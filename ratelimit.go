@@ -0,0 +1,253 @@
+package gmadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RateLimit 描述一个令牌桶：每秒补充 Rate 个令牌，最多积累 Burst 个
+type RateLimit struct {
+	Rate  float64 `json:"rate,omitempty" yaml:"rate,omitempty"`
+	Burst int     `json:"burst,omitempty" yaml:"burst,omitempty"`
+}
+
+// RateLimitConfig 是工具调用的限流配置，为空的维度不限流
+type RateLimitConfig struct {
+	// Global 所有工具调用共享的限流
+	Global *RateLimit `json:"global,omitempty" yaml:"global,omitempty"`
+	// PerBackend 每个后端的限流
+	PerBackend *RateLimit `json:"per_backend,omitempty" yaml:"per_backend,omitempty"`
+	// PerSession 每个 MCP 会话的限流
+	PerSession *RateLimit `json:"per_session,omitempty" yaml:"per_session,omitempty"`
+	// PerTool 指定工具的限流，未列出的工具使用 DefaultPerTool
+	PerTool        map[string]RateLimit `json:"per_tool,omitempty" yaml:"per_tool,omitempty"`
+	DefaultPerTool *RateLimit           `json:"default_per_tool,omitempty" yaml:"default_per_tool,omitempty"`
+	// MaxWait 大于 0 时超限的调用排队等待令牌，最多等待该时长，超过后才拒绝
+	MaxWait time.Duration `json:"max_wait,omitempty" yaml:"max_wait,omitempty"`
+}
+
+// sessionBucketIdle 会话令牌桶闲置多久后被清理
+const sessionBucketIdle = 10 * time.Minute
+
+// WithRateLimit 开启工具调用限流，每个令牌桶的 Rate 必须大于 0、Burst 至少为 1，否则 NewOpenAPIToMCPAdapter 返回错误
+func WithRateLimit(cfg RateLimitConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.rateLimitConfig = &cfg
+	}
+}
+
+// tokenBucket 是一个支持预约的令牌桶
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket 创建装满令牌的令牌桶
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	burst := float64(limit.Burst)
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: now}
+}
+
+// take 取一个令牌；令牌不足时若等待时间不超过 maxWait 则预约并返回需要等待的时间，否则返回 false 和建议的重试间隔
+func (b *tokenBucket) take(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if wait > maxWait {
+		return wait, false
+	}
+	b.tokens--
+	return wait, true
+}
+
+// refund 归还一个已取走的令牌
+func (b *tokenBucket) refund() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+	b.mu.Unlock()
+}
+
+// idle 判断令牌桶是否已闲置
+func (b *tokenBucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return now.Sub(b.last) > sessionBucketIdle
+}
+
+// rateLimiter 组合多个维度的令牌桶
+type rateLimiter struct {
+	cfg     RateLimitConfig
+	now     func() time.Time
+	global  *tokenBucket
+	backend *tokenBucket
+
+	mu       sync.Mutex
+	tools    map[string]*tokenBucket
+	sessions map[string]*tokenBucket
+}
+
+// newRateLimiter 根据配置创建限流器，令牌桶参数无效时返回错误
+func newRateLimiter(cfg RateLimitConfig) (*rateLimiter, error) {
+	check := func(name string, limit *RateLimit) error {
+		if limit == nil {
+			return nil
+		}
+		if !(limit.Rate > 0) {
+			return fmt.Errorf("rate limit %s: rate must be positive, got %v", name, limit.Rate)
+		}
+		if limit.Burst < 1 {
+			return fmt.Errorf("rate limit %s: burst must be at least 1, got %d", name, limit.Burst)
+		}
+		return nil
+	}
+	tools := make([]string, 0, len(cfg.PerTool))
+	for name := range cfg.PerTool {
+		tools = append(tools, name)
+	}
+	sort.Strings(tools)
+	errs := []error{check("global", cfg.Global), check("per_backend", cfg.PerBackend), check("per_session", cfg.PerSession), check("default_per_tool", cfg.DefaultPerTool)}
+	for _, name := range tools {
+		limit := cfg.PerTool[name]
+		errs = append(errs, check("per_tool."+name, &limit))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	l := &rateLimiter{
+		cfg:      cfg,
+		now:      time.Now,
+		tools:    make(map[string]*tokenBucket),
+		sessions: make(map[string]*tokenBucket),
+	}
+	if cfg.Global != nil {
+		l.global = newTokenBucket(*cfg.Global, l.now())
+	}
+	if cfg.PerBackend != nil {
+		l.backend = newTokenBucket(*cfg.PerBackend, l.now())
+	}
+	return l, nil
+}
+
+// limitedBucket 是参与本次调用的令牌桶和它的维度名
+type limitedBucket struct {
+	scope  string
+	bucket *tokenBucket
+}
+
+// buckets 返回本次调用需要经过的令牌桶
+func (l *rateLimiter) buckets(ctx context.Context, toolName string) []limitedBucket {
+	var buckets []limitedBucket
+	if l.global != nil {
+		buckets = append(buckets, limitedBucket{"global", l.global})
+	}
+	if l.backend != nil {
+		buckets = append(buckets, limitedBucket{"backend", l.backend})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	if limit, ok := l.cfg.PerTool[toolName]; ok || l.cfg.DefaultPerTool != nil {
+		if !ok {
+			limit = *l.cfg.DefaultPerTool
+		}
+		b, exists := l.tools[toolName]
+		if !exists {
+			b = newTokenBucket(limit, now)
+			l.tools[toolName] = b
+		}
+		buckets = append(buckets, limitedBucket{"tool " + toolName, b})
+	}
+
+	if l.cfg.PerSession != nil {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			b, exists := l.sessions[session.SessionID()]
+			if !exists {
+				for id, idle := range l.sessions {
+					if idle.idle(now) {
+						delete(l.sessions, id)
+					}
+				}
+				b = newTokenBucket(*l.cfg.PerSession, now)
+				l.sessions[session.SessionID()] = b
+			}
+			buckets = append(buckets, limitedBucket{"session", b})
+		}
+	}
+	return buckets
+}
+
+// wait 在所有维度上取令牌，必要时排队等待；被拒绝时返回超限的维度和建议的重试间隔
+func (l *rateLimiter) wait(ctx context.Context, toolName string) (string, time.Duration, error) {
+	buckets := l.buckets(ctx, toolName)
+	now := l.now()
+
+	var longest time.Duration
+	for i, b := range buckets {
+		wait, ok := b.bucket.take(now, l.cfg.MaxWait)
+		if !ok {
+			for _, taken := range buckets[:i] {
+				taken.bucket.refund()
+			}
+			return b.scope, wait, nil
+		}
+		if wait > longest {
+			longest = wait
+		}
+	}
+	if longest == 0 {
+		return "", 0, nil
+	}
+
+	timer := time.NewTimer(longest)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return "", 0, nil
+	case <-ctx.Done():
+		for _, b := range buckets {
+			b.bucket.refund()
+		}
+		return "", 0, ctx.Err()
+	}
+}
+
+// checkRateLimit 对工具调用限流，超限时返回带重试提示的错误结果
+func (a *OpenAPIToMCPAdapter) checkRateLimit(ctx context.Context, toolName string) (*mcp.CallToolResult, error) {
+	if a.rateLimiter == nil {
+		return nil, nil
+	}
+	scope, retryAfter, err := a.rateLimiter.wait(ctx, toolName)
+	if err != nil || scope == "" {
+		return nil, err
+	}
+
+	a.metrics.add("mcp_adapter_rate_limited_total", 1, "tool", toolName, "scope", scope)
+	seconds := math.Ceil(retryAfter.Seconds())
+	result := mcp.NewToolResultError(fmt.Sprintf("rate limit exceeded for %s; retry after %.0f seconds", scope, seconds))
+	result.Meta = map[string]interface{}{"retryAfter": seconds}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_RejectsWithRetryAfter(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithRateLimit(RateLimitConfig{
+		PerTool: map[string]RateLimit{"_whoami_get": {Rate: 0.5, Burst: 1}},
+	}))
+
+	result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.False(t, result.IsError)
+
+	result, err = adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(mcp.TextContent).Text, "tool _whoami_get")
+	assert.Equal(t, 2.0, result.Meta["retryAfter"])
+}
+
+func TestRateLimit_PerSessionAndQueue(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithRateLimit(RateLimitConfig{
+		PerSession: &RateLimit{Rate: 20, Burst: 1},
+		MaxWait:    time.Second,
+	}))
+
+	alice := adapter.server.WithContext(context.Background(), &fakeSession{id: "alice"})
+	bob := adapter.server.WithContext(context.Background(), &fakeSession{id: "bob"})
+
+	start := time.Now()
+	for _, ctx := range []context.Context{alice, bob, alice} {
+		result, err := adapter.handlers["_whoami_get"](ctx, mcp.CallToolRequest{})
+		assert.NoError(t, err)
+		assert.False(t, result.IsError)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestTokenBucket_Refill(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(RateLimit{Rate: 1, Burst: 2}, now)
+
+	for i := 0; i < 2; i++ {
+		_, ok := b.take(now, 0)
+		assert.True(t, ok)
+	}
+	wait, ok := b.take(now, 0)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	_, ok = b.take(now.Add(time.Second), 0)
+	assert.True(t, ok)
+}
+
+func TestRateLimit_InvalidLimits(t *testing.T) {
+	_, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0", WithRateLimit(RateLimitConfig{
+		Global:  &RateLimit{Burst: 1},
+		PerTool: map[string]RateLimit{"_whoami_get": {Rate: 1}},
+	}))
+	assert.EqualError(t, err, "rate limit global: rate must be positive, got 0\nrate limit per_tool._whoami_get: burst must be at least 1, got 0")
+
+	_, err = NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0", WithRateLimit(RateLimitConfig{
+		PerSession: &RateLimit{Rate: 1, Burst: 1},
+	}))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,181 @@
+package gmadapter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// 工具的来源
+const (
+	// ToolSourceOpenAPI 从 LoadOpenAPI 加载的文档生成，热加载时随文档更新
+	ToolSourceOpenAPI = "openapi"
+	// ToolSourceCustom 通过 AddTool、ReplaceTool 或 AddOperation 在运行时注册，热加载不会修改
+	ToolSourceCustom = "custom"
+	// ToolSourceMerged 通过 Merge 从其他适配器合并进来
+	ToolSourceMerged = "merged"
+)
+
+// ToolInfo 描述适配器上的一个工具
+type ToolInfo struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Tools 返回适配器上的所有工具，包括停用的工具，按工具名排序
+func (a *OpenAPIToMCPAdapter) Tools() []ToolInfo {
+	a.toolsMu.RLock()
+	defer a.toolsMu.RUnlock()
+	infos := make([]ToolInfo, 0, len(a.tools))
+	for toolName := range a.tools {
+		source := ToolSourceOpenAPI
+		switch {
+		case a.mergedTools[toolName] != nil:
+			source = ToolSourceMerged
+		case a.customTools[toolName]:
+			source = ToolSourceCustom
+		}
+		infos = append(infos, ToolInfo{Name: toolName, Source: source, Enabled: !a.disabled[toolName]})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// AddTool 注册自定义工具，工具名已存在时返回错误。
+// 可以在 Start 之后调用，已连接的会话会收到工具列表变化的通知，合并了当前适配器的适配器和 AttachTo 的服务器同步更新。
+func (a *OpenAPIToMCPAdapter) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) error {
+	return a.putTool(&tool, handler, false)
+}
+
+// ReplaceTool 注册自定义工具，工具名已存在时替换原有工具。
+// 替换从文档生成的工具后，热加载不再修改这个工具；合并进来的工具不能替换。
+func (a *OpenAPIToMCPAdapter) ReplaceTool(tool mcp.Tool, handler server.ToolHandlerFunc) error {
+	return a.putTool(&tool, handler, true)
+}
+
+// AddOperation 从一个 OpenAPI 操作对象生成工具并注册，工具名已存在时替换原有工具，返回工具名。
+// 工具按适配器的命名、超时、重试和缓存等配置调用后端，不经过 WithFilter 过滤。
+func (a *OpenAPIToMCPAdapter) AddOperation(method, path string, operation map[string]interface{}) (string, error) {
+	tool, handler, err := a.buildTool(strings.ToLower(method), path, operation)
+	if err != nil {
+		return "", fmt.Errorf("generate tool for %s %s: %w", method, path, err)
+	}
+	if tool == nil {
+		return "", fmt.Errorf("generate tool for %s %s: unsupported request body", method, path)
+	}
+	return tool.Name, a.putTool(tool, handler, true)
+}
+
+// putTool 注册运行时添加的工具并发布改动
+func (a *OpenAPIToMCPAdapter) putTool(tool *mcp.Tool, handler server.ToolHandlerFunc, replace bool) error {
+	if tool.Name == "" {
+		return fmt.Errorf("tool name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("tool %s: handler is required", tool.Name)
+	}
+
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	a.toolsMu.Lock()
+	_, exists := a.tools[tool.Name]
+	switch {
+	case a.mergedTools[tool.Name] != nil:
+		a.toolsMu.Unlock()
+		return fmt.Errorf("tool %s is merged from another adapter", tool.Name)
+	case exists && !replace:
+		a.toolsMu.Unlock()
+		return fmt.Errorf("tool %s is already registered", tool.Name)
+	}
+	a.tools[tool.Name] = tool
+	a.handlers[tool.Name] = handler
+	if a.customTools == nil {
+		a.customTools = make(map[string]bool)
+	}
+	a.customTools[tool.Name] = true
+	a.toolsMu.Unlock()
+
+	if exists {
+		a.publish(ReloadResult{Updated: []string{tool.Name}})
+	} else {
+		a.publish(ReloadResult{Added: []string{tool.Name}})
+	}
+	return nil
+}
+
+// RemoveTool 删除工具。从文档生成的工具在文档下次变化并热加载时会重新生成，需要保持隐藏时使用 DisableTool。
+func (a *OpenAPIToMCPAdapter) RemoveTool(name string) error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	a.toolsMu.Lock()
+	if _, ok := a.tools[name]; !ok {
+		a.toolsMu.Unlock()
+		return fmt.Errorf("tool %s is not registered", name)
+	}
+	delete(a.tools, name)
+	delete(a.handlers, name)
+	delete(a.customTools, name)
+	delete(a.mergedTools, name)
+	delete(a.disabled, name)
+	a.toolsMu.Unlock()
+
+	a.publish(ReloadResult{Removed: []string{name}})
+	return nil
+}
+
+// DisableTool 停用工具：工具不再出现在工具列表中，调用会返回错误，EnableTool 后恢复。停用状态在热加载后保留。
+func (a *OpenAPIToMCPAdapter) DisableTool(name string) error {
+	return a.setEnabled(name, false)
+}
+
+// EnableTool 恢复 DisableTool 停用的工具
+func (a *OpenAPIToMCPAdapter) EnableTool(name string) error {
+	return a.setEnabled(name, true)
+}
+
+// setEnabled 修改工具的启用状态并发布改动
+func (a *OpenAPIToMCPAdapter) setEnabled(name string, enabled bool) error {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	a.toolsMu.Lock()
+	if _, ok := a.tools[name]; !ok {
+		a.toolsMu.Unlock()
+		return fmt.Errorf("tool %s is not registered", name)
+	}
+	if a.disabled[name] != enabled {
+		a.toolsMu.Unlock()
+		return nil
+	}
+	if enabled {
+		delete(a.disabled, name)
+	} else {
+		if a.disabled == nil {
+			a.disabled = make(map[string]bool)
+		}
+		a.disabled[name] = true
+	}
+	a.toolsMu.Unlock()
+
+	if enabled {
+		a.publish(ReloadResult{Added: []string{name}})
+	} else {
+		a.publish(ReloadResult{Removed: []string{name}})
+	}
+	return nil
+}
+
+// enabledTools 返回未停用的工具名，按工具名排序，调用方需持有 toolsMu
+func (a *OpenAPIToMCPAdapter) enabledTools() []string {
+	names := make([]string, 0, len(a.tools))
+	for toolName := range a.tools {
+		if !a.disabled[toolName] {
+			names = append(names, toolName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
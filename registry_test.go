@@ -0,0 +1,147 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+// callTool 通过 MCP 服务器调用工具，返回响应的 JSON
+func callTool(s interface {
+	HandleMessage(context.Context, json.RawMessage) mcp.JSONRPCMessage
+}, name string) string {
+	data, _ := json.Marshal(s.HandleMessage(context.Background(), []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":{}}}`, name))))
+	return string(data)
+}
+
+func TestRuntimeRegistration(t *testing.T) {
+	backend := newEchoBackend("backend")
+	defer backend.Close()
+
+	a := newIdentityTestAdapter(t, backend.URL)
+	a.registerTools()
+	data, _ := json.Marshal(a.server.HandleMessage(context.Background(), []byte(initializeMessage)))
+	assert.Contains(t, string(data), `"listChanged":true`)
+	session := newChannelSession()
+	session.Initialize()
+	assert.NoError(t, a.server.RegisterSession(context.Background(), session))
+
+	hello := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("hello"), nil
+	}
+	assert.NoError(t, a.AddTool(mcp.NewTool("hello"), hello))
+	assert.ErrorContains(t, a.AddTool(mcp.NewTool("hello"), hello), "already registered")
+	assert.ElementsMatch(t, []string{"_whoami_get", "hello"}, listToolNames(t, a.server))
+	assert.Equal(t, "notifications/tools/list_changed", (<-session.notifications).Method)
+	assert.Contains(t, callTool(a.server, "hello"), "hello")
+
+	assert.NoError(t, a.ReplaceTool(mcp.NewTool("hello"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("bonjour"), nil
+	}))
+	assert.Contains(t, callTool(a.server, "hello"), "bonjour")
+
+	// 停用的工具不在列表中，调用返回错误，恢复后可以再次调用
+	assert.NoError(t, a.DisableTool("_whoami_get"))
+	assert.Equal(t, []string{"hello"}, listToolNames(t, a.server))
+	result, err := a.toolHandler("_whoami_get")(context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.NoError(t, a.EnableTool("_whoami_get"))
+	assert.ElementsMatch(t, []string{"_whoami_get", "hello"}, listToolNames(t, a.server))
+	assert.Contains(t, callTool(a.server, "_whoami_get"), "backend")
+
+	name, err := a.AddOperation("GET", "/status", map[string]interface{}{"summary": "Status"})
+	assert.NoError(t, err)
+	assert.Equal(t, "_status_get", name)
+	assert.Contains(t, callTool(a.server, "_status_get"), "backend")
+
+	// 格式错误的操作返回校验错误，不注册工具
+	_, err = a.AddOperation("GET", "/broken", map[string]interface{}{
+		"parameters": []interface{}{map[string]interface{}{"name": "id", "schema": 1}},
+	})
+	assert.ErrorContains(t, err, "generate tool for GET /broken: schema of parameter id is not an object")
+
+	assert.NoError(t, a.RemoveTool("hello"))
+	assert.ErrorContains(t, a.RemoveTool("hello"), "not registered")
+	assert.ErrorContains(t, a.DisableTool("hello"), "not registered")
+	assert.Equal(t, []ToolInfo{
+		{Name: "_status_get", Source: ToolSourceCustom, Enabled: true},
+		{Name: "_whoami_get", Source: ToolSourceOpenAPI, Enabled: true},
+	}, a.Tools())
+}
+
+func TestRuntimeRegistration_SurvivesReload(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(reloadSpecV1), 0644))
+	member, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0")
+	assert.NoError(t, err)
+	assert.NoError(t, member.LoadOpenAPI(spec))
+	assert.NoError(t, member.GenerateTools())
+	host, err := NewOpenAPIToMCPAdapter("gateway", "1.0.0", "", "localhost:0")
+	assert.NoError(t, err)
+	_, err = host.Merge(member, "shop")
+	assert.NoError(t, err)
+
+	assert.NoError(t, member.AddTool(mcp.NewTool("_ping"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("pong"), nil
+	}))
+	assert.NoError(t, member.DisableTool("_users_get"))
+	assert.ElementsMatch(t, []string{"shop_orders_get", "shop_ping"}, listToolNames(t, host.server))
+
+	// 热加载不影响运行时注册的工具和停用状态
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(reloadSpecV2), 0644))
+	_, err = member.Reload(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"_invoices_get", "_ping"}, listToolNames(t, member.server))
+	assert.ElementsMatch(t, []string{"shop_invoices_get", "shop_ping"}, listToolNames(t, host.server))
+	assert.Equal(t, []ToolInfo{
+		{Name: "shop_invoices_get", Source: ToolSourceMerged, Enabled: true},
+		{Name: "shop_ping", Source: ToolSourceMerged, Enabled: true},
+	}, host.Tools())
+
+	assert.NoError(t, member.EnableTool("_users_get"))
+	assert.ElementsMatch(t, []string{"shop_invoices_get", "shop_users_get", "shop_ping"}, listToolNames(t, host.server))
+	_, err = host.Merge(member, "shop")
+	assert.ErrorContains(t, err, "already registered")
+	assert.ErrorContains(t, host.ReplaceTool(mcp.NewTool("shop_ping"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, nil
+	}), "merged from another adapter")
+}
+
+func TestRuntimeRegistration_Concurrent(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+	a := newIdentityTestAdapter(t, backend.URL)
+	a.registerTools()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("tool_%d", i)
+			for j := 0; j < 20; j++ {
+				assert.NoError(t, a.ReplaceTool(mcp.NewTool(name), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					return mcp.NewToolResultText("ok"), nil
+				}))
+				a.DisableTool(name)
+				a.EnableTool(name)
+				callTool(a.server, name)
+				listToolNames(t, a.server)
+				a.Tools()
+				assert.NoError(t, a.RemoveTool(name))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"_whoami_get"}, listToolNames(t, a.server))
+}
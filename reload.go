@@ -0,0 +1,386 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ReloadConfig 是 OpenAPI 文档热加载的配置
+type ReloadConfig struct {
+	// Interval 轮询 URL 来源的间隔，默认 30s；本地文件通过文件系统通知监听，通知不可用时也按这个间隔检查
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// Debounce 收到文件变化通知后等待的时间，合并一次保存产生的多个事件，默认 500ms
+	Debounce time.Duration `json:"debounce,omitempty" yaml:"debounce,omitempty"`
+	// OnReload 文档变化并重新加载后调用，err 非空表示新文档加载失败，原有的工具保持不变
+	OnReload func(ReloadResult, error) `json:"-" yaml:"-"`
+}
+
+// ReloadResult 是一次热加载对工具的改动，工具名不包含 Merge 和 AttachTo 的前缀
+type ReloadResult struct {
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+// Changed 判断工具是否有变化
+func (r ReloadResult) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) > 0
+}
+
+// WithSpecReload 监听 LoadOpenAPI 加载的文档，变化时重新生成工具，只增删有变化的工具并通知客户端工具列表已变化。
+// 新文档无法加载或生成工具失败时保留原有的工具。
+func WithSpecReload(cfg ReloadConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		if cfg.Interval <= 0 {
+			cfg.Interval = 30 * time.Second
+		}
+		if cfg.Debounce <= 0 {
+			cfg.Debounce = 500 * time.Millisecond
+		}
+		a.reloadConfig = &cfg
+	}
+}
+
+// Reload 重新读取 LoadOpenAPI 或 LoadOpenAPIFS 加载的文档，内容变化时重新生成工具，
+// 更新当前适配器、合并了它的适配器以及 AttachTo 的服务器上有变化的工具。
+// 新文档无法加载或生成工具失败时返回错误，原有的工具保持不变。
+func (a *OpenAPIToMCPAdapter) Reload(ctx context.Context) (ReloadResult, error) {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	source := a.spec.source
+	if !a.spec.reloadable() {
+		return ReloadResult{}, errors.New("the OpenAPI document was not loaded from a file or URL")
+	}
+	data, version, err := a.fetchSpec(ctx, a.spec)
+	if err == errUnchanged {
+		a.spec = version
+		return ReloadResult{}, nil
+	}
+	var result ReloadResult
+	if err == nil {
+		result, err = a.applySpec(data)
+	}
+	if err != nil {
+		a.metrics.add("mcp_adapter_spec_reloads_total", 1, "result", "error")
+		a.log().Printf("reload OpenAPI spec %s: %v; keeping the previous tools", source, err)
+		if a.reloadConfig != nil && a.reloadConfig.OnReload != nil {
+			a.reloadConfig.OnReload(ReloadResult{}, err)
+		}
+		return ReloadResult{}, err
+	}
+	a.spec = version
+	a.saveSpecCache(version, data)
+
+	a.metrics.add("mcp_adapter_spec_reloads_total", 1, "result", "ok")
+	a.log().Printf("reloaded OpenAPI spec %s: %d added, %d updated, %d removed",
+		source, len(result.Added), len(result.Updated), len(result.Removed))
+	if a.reloadConfig != nil && a.reloadConfig.OnReload != nil {
+		a.reloadConfig.OnReload(result, nil)
+	}
+	return result, nil
+}
+
+// applySpec 从新文档生成工具，替换从文档生成的工具并发布改动，合并进来的工具和运行时注册的工具不受影响
+func (a *OpenAPIToMCPAdapter) applySpec(data []byte) (result ReloadResult, err error) {
+	openAPI, err := parseSpec(data)
+	if err != nil {
+		return result, err
+	}
+	tools, handlers, err := a.buildTools(openAPI)
+	if err != nil {
+		return result, err
+	}
+
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	a.toolsMu.Lock()
+	for toolName, tool := range tools {
+		if !a.fromSpec(toolName) {
+			a.log().Printf("tool %s is already registered by Merge or at runtime, skipping it", toolName)
+			delete(tools, toolName)
+			continue
+		}
+		previous, ok := a.tools[toolName]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, toolName)
+		case !sameTool(previous, tool):
+			result.Updated = append(result.Updated, toolName)
+		}
+	}
+	for toolName := range a.tools {
+		if !a.fromSpec(toolName) {
+			continue
+		}
+		if _, ok := tools[toolName]; !ok {
+			result.Removed = append(result.Removed, toolName)
+		}
+	}
+	sort.Strings(result.Added)
+	sort.Strings(result.Updated)
+	sort.Strings(result.Removed)
+
+	// 未变化的工具也替换处理函数，超时、缓存等来自文档的配置随之更新
+	for _, toolName := range result.Removed {
+		delete(a.tools, toolName)
+		delete(a.handlers, toolName)
+	}
+	for toolName, tool := range tools {
+		a.tools[toolName] = tool
+		a.handlers[toolName] = handlers[toolName]
+	}
+	a.openAPI = openAPI
+	a.toolsMu.Unlock()
+
+	if result.Changed() {
+		a.publish(result)
+	}
+	return result, nil
+}
+
+// fromSpec 判断工具是否从当前适配器的文档生成，调用方需持有 toolsMu
+func (a *OpenAPIToMCPAdapter) fromSpec(toolName string) bool {
+	return a.mergedTools[toolName] == nil && !a.customTools[toolName]
+}
+
+// publish 把工具的改动发布到当前适配器的服务器、AttachTo 的服务器以及合并了当前适配器的适配器，调用方需持有 updateMu
+func (a *OpenAPIToMCPAdapter) publish(result ReloadResult) {
+	a.toolsMu.RLock()
+	tools := a.changedTools(result, "", a.toolHandler)
+	a.toolsMu.RUnlock()
+	updateServerTools(a.server, tools, result.Removed)
+
+	a.syncAttached(result)
+	a.membersMu.Lock()
+	hosts := append([]*OpenAPIToMCPAdapter{}, a.hosts...)
+	a.membersMu.Unlock()
+	for _, host := range hosts {
+		host.syncMember(a, result)
+	}
+}
+
+// sameTool 判断两个工具的定义是否相同
+func sameTool(a, b *mcp.Tool) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(x) == string(y)
+}
+
+// toolHandler 返回按工具名查找当前处理函数的处理函数，热加载替换处理函数后无需重新注册工具
+func (a *OpenAPIToMCPAdapter) toolHandler(toolName string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		a.toolsMu.RLock()
+		handler, ok := a.handlers[toolName]
+		disabled := a.disabled[toolName]
+		a.toolsMu.RUnlock()
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %s is no longer available", toolName)), nil
+		}
+		if disabled {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %s is disabled", toolName)), nil
+		}
+		return handler(ctx, request)
+	}
+}
+
+// changedTools 返回新增和修改的工具，工具名加上 prefix，停用的工具不会返回，调用方需持有 toolsMu
+func (a *OpenAPIToMCPAdapter) changedTools(result ReloadResult, prefix string, handler func(toolName string) server.ToolHandlerFunc) []server.ServerTool {
+	var tools []server.ServerTool
+	for _, names := range [][]string{result.Added, result.Updated} {
+		for _, toolName := range names {
+			if _, ok := a.tools[toolName]; !ok || a.disabled[toolName] {
+				continue
+			}
+			tool := *a.tools[toolName]
+			tool.Name = prefix + toolName
+			tools = append(tools, server.ServerTool{Tool: tool, Handler: handler(toolName)})
+		}
+	}
+	return tools
+}
+
+// prefixed 返回加上 prefix 的工具名
+func prefixed(prefix string, names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		result = append(result, prefix+name)
+	}
+	return result
+}
+
+// updateServerTools 在服务器上添加或替换 tools 并删除 removed，每一步都会通知客户端工具列表已变化
+func updateServerTools(s *server.MCPServer, tools []server.ServerTool, removed []string) {
+	if len(tools) > 0 {
+		s.AddTools(tools...)
+	}
+	if len(removed) > 0 {
+		s.DeleteTools(removed...)
+	}
+}
+
+// syncAttached 把热加载的改动同步到 AttachTo 的服务器上
+func (a *OpenAPIToMCPAdapter) syncAttached(result ReloadResult) {
+	a.attachMu.Lock()
+	defer a.attachMu.Unlock()
+	for s, att := range a.attached {
+		a.toolsMu.RLock()
+		tools := a.changedTools(result, att.prefix, a.toolHandler)
+		names := make([]string, 0, len(a.tools))
+		for _, toolName := range a.enabledTools() {
+			names = append(names, att.prefix+toolName)
+		}
+		a.toolsMu.RUnlock()
+		sort.Strings(names)
+		a.attached[s] = attachment{prefix: att.prefix, names: names}
+		updateServerTools(s, tools, prefixed(att.prefix, result.Removed))
+	}
+}
+
+// syncMember 把合并进来的适配器热加载的改动同步到当前适配器，与当前适配器已有工具冲突的新工具会被跳过
+func (a *OpenAPIToMCPAdapter) syncMember(member *OpenAPIToMCPAdapter, result ReloadResult) {
+	a.membersMu.Lock()
+	defer a.membersMu.Unlock()
+	prefix := a.memberPrefixes[member]
+
+	member.toolsMu.RLock()
+	tools := member.changedTools(result, prefix, func(toolName string) server.ToolHandlerFunc {
+		return a.mergedHandler(member, prefix+toolName, member.toolHandler(toolName))
+	})
+	member.toolsMu.RUnlock()
+
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+	added := tools[:0]
+	for _, tool := range tools {
+		if _, ok := a.tools[tool.Tool.Name]; ok && a.mergedTools[tool.Tool.Name] != member {
+			a.log().Printf("tool %s is already registered, skipping it", tool.Tool.Name)
+			continue
+		}
+		tool := tool
+		a.tools[tool.Tool.Name] = &tool.Tool
+		a.handlers[tool.Tool.Name] = tool.Handler
+		a.mergedTools[tool.Tool.Name] = member
+		if !a.disabled[tool.Tool.Name] {
+			added = append(added, tool)
+		}
+	}
+	var removed []string
+	for _, toolName := range prefixed(prefix, result.Removed) {
+		if a.mergedTools[toolName] != member {
+			continue
+		}
+		delete(a.tools, toolName)
+		delete(a.handlers, toolName)
+		delete(a.mergedTools, toolName)
+		removed = append(removed, toolName)
+	}
+	updateServerTools(a.server, added, removed)
+}
+
+// watchSpec 监听文档来源，变化时重新加载，直到 ctx 结束。
+// 本地文件通过文件系统通知监听，无法监听时退回按间隔检查；URL 按间隔发起条件请求，fs.FS 中的文件按间隔检查。
+func (a *OpenAPIToMCPAdapter) watchSpec(ctx context.Context) {
+	a.reloadMu.Lock()
+	spec := a.spec
+	a.reloadMu.Unlock()
+	if !spec.reloadable() {
+		a.log().Printf("spec reload is enabled but the OpenAPI document was not loaded from a file or URL")
+		return
+	}
+
+	if spec.path != "" && spec.fsys == nil {
+		err := a.watchSpecFile(ctx, spec.path)
+		if err == nil {
+			return
+		}
+		a.log().Printf("watch OpenAPI spec %s: %v; polling every %s instead", spec.source, err, a.reloadConfig.Interval)
+	}
+
+	ticker := time.NewTicker(a.reloadConfig.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Reload(ctx)
+		}
+	}
+}
+
+// watchSpecFile 通过文件系统通知监听本地文档，直到 ctx 结束
+func (a *OpenAPIToMCPAdapter) watchSpecFile(ctx context.Context, path string) error {
+	watcher, err := newFileWatcher(path)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	a.log().Printf("watching OpenAPI spec %s for changes", path)
+	watchFileEvents(ctx, watcher, path, a.reloadConfig.Debounce, func(err error) {
+		a.log().Printf("watch OpenAPI spec %s: %v", path, err)
+	}, func() {
+		a.Reload(ctx)
+	})
+	return nil
+}
+
+// newFileWatcher 创建监听 path 的文件系统通知。
+// 监听所在目录而不是文件本身，编辑器替换文件或 Kubernetes 切换 ConfigMap 的符号链接时也能收到通知。
+func newFileWatcher(path string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// watchFileEvents 处理 newFileWatcher 的通知，path 变化后等待 debounce 合并同一次保存产生的多个事件再调用 changed，直到 ctx 结束
+func watchFileEvents(ctx context.Context, watcher *fsnotify.Watcher, path string, debounce time.Duration, onError func(error), changed func()) {
+	name := filepath.Base(path)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			base := filepath.Base(event.Name)
+			if event.Op == fsnotify.Chmod || (base != name && !strings.HasPrefix(base, "..")) {
+				continue
+			}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			onError(err)
+		case <-timer.C:
+			changed()
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package gmadapter
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+const reloadSpecV1 = `
+openapi: 3.0.0
+paths:
+  /users:
+    get:
+      summary: List users
+  /orders:
+    get:
+      summary: List orders
+`
+
+const reloadSpecV2 = `
+openapi: 3.0.0
+paths:
+  /users:
+    get:
+      summary: List all users
+  /invoices:
+    get:
+      summary: List invoices
+`
+
+func TestReload_WatchesFile(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(reloadSpecV1), 0644))
+
+	reloads := make(chan error, 10)
+	var last ReloadResult
+	var mu sync.Mutex
+	a, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0", WithSpecReload(ReloadConfig{
+		Debounce: 10 * time.Millisecond,
+		OnReload: func(result ReloadResult, err error) {
+			mu.Lock()
+			last = result
+			mu.Unlock()
+			reloads <- err
+		},
+	}))
+	assert.NoError(t, err)
+	assert.NoError(t, a.LoadOpenAPI(spec))
+	assert.NoError(t, a.GenerateTools())
+	a.registerTools()
+
+	session := newChannelSession()
+	session.Initialize()
+	assert.NoError(t, a.server.RegisterSession(context.Background(), session))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.StartBackground(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(reloadSpecV2), 0644))
+	select {
+	case err := <-reloads:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("spec change was not picked up")
+	}
+	mu.Lock()
+	assert.Equal(t, ReloadResult{Added: []string{"_invoices_get"}, Updated: []string{"_users_get"}, Removed: []string{"_orders_get"}}, last)
+	mu.Unlock()
+	assert.ElementsMatch(t, []string{"_users_get", "_invoices_get"}, listToolNames(t, a.server))
+	select {
+	case notification := <-session.notifications:
+		assert.Equal(t, "notifications/tools/list_changed", notification.Method)
+	default:
+		t.Fatal("no tools/list_changed notification")
+	}
+
+	// 新文档无效时保留原有的工具
+	assert.NoError(t, ioutil.WriteFile(spec, []byte("paths: ["), 0644))
+	select {
+	case err := <-reloads:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("spec change was not picked up")
+	}
+	assert.ElementsMatch(t, []string{"_users_get", "_invoices_get"}, listToolNames(t, a.server))
+	assert.Equal(t, 1.0, a.Metrics()[`mcp_adapter_spec_reloads_total{result="error"}`])
+}
+
+func TestReload_URLWithETag(t *testing.T) {
+	var mu sync.Mutex
+	body, etag := reloadSpecV1, `"v1"`
+	var notModified int
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer specServer.Close()
+
+	member, err := NewOpenAPIToMCPAdapter("orders", "1.0.0", "http://backend", "localhost:0", WithSpecReload(ReloadConfig{}))
+	assert.NoError(t, err)
+	assert.NoError(t, member.LoadOpenAPI(specServer.URL))
+	assert.NoError(t, member.GenerateTools())
+
+	host, err := NewOpenAPIToMCPAdapter("gateway", "1.0.0", "", "localhost:0")
+	assert.NoError(t, err)
+	_, err = host.Merge(member, "shop")
+	assert.NoError(t, err)
+	attached := server.NewMCPServer("mine", "1.0.0")
+	member.AttachTo(attached, "v1")
+
+	result, err := member.Reload(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, result.Changed())
+	assert.Equal(t, 1, notModified)
+
+	mu.Lock()
+	body, etag = reloadSpecV2, `"v2"`
+	mu.Unlock()
+	result, err = member.Reload(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"_invoices_get"}, result.Added)
+	assert.Equal(t, []string{"_orders_get"}, result.Removed)
+
+	// 合并了它的适配器和挂载了它的服务器同步更新
+	assert.ElementsMatch(t, []string{"shop_users_get", "shop_invoices_get"}, listToolNames(t, host.server))
+	assert.ElementsMatch(t, []string{"v1_users_get", "v1_invoices_get"}, listToolNames(t, attached))
+
+	// 后端不可用时保留原有的工具
+	specServer.Close()
+	_, err = member.Reload(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, member.tools, 2)
+}
@@ -0,0 +1,276 @@
+package gmadapter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy 描述后端调用失败时的重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最多尝试次数（包含第一次），小于等于 1 表示不重试
+	MaxAttempts int `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	// InitialBackoff 第一次重试前的等待时间，默认 100ms
+	InitialBackoff time.Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"`
+	// MaxBackoff 单次等待时间上限，默认 5s；后端的 Retry-After 超过该值时不再重试，直接返回后端的响应
+	MaxBackoff time.Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+	// Multiplier 每次重试等待时间的增长倍数，默认 2
+	Multiplier float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	// Jitter 随机抖动比例（0~1），默认 0.2，负数表示关闭抖动
+	Jitter float64 `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	// RetryableStatus 触发重试的状态码，默认 502、503、504
+	RetryableStatus []int `json:"retryable_status,omitempty" yaml:"retryable_status,omitempty"`
+	// RetryNonIdempotent 允许重试 POST、PATCH 等非幂等方法
+	RetryNonIdempotent bool `json:"retry_non_idempotent,omitempty" yaml:"retry_non_idempotent,omitempty"`
+	// IdempotencyKeyHeader 非空时为非幂等请求生成幂等键并写入该头，使其可以安全重试
+	IdempotencyKeyHeader string `json:"idempotency_key_header,omitempty" yaml:"idempotency_key_header,omitempty"`
+}
+
+// WithRetryPolicy 设置所有工具默认的重试策略
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.retryPolicy = p.withDefaults()
+	}
+}
+
+// WithOperationRetryPolicy 为指定工具设置重试策略
+func WithOperationRetryPolicy(toolName string, p RetryPolicy) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		if a.operationRetries == nil {
+			a.operationRetries = make(map[string]*RetryPolicy)
+		}
+		a.operationRetries[toolName] = p.withDefaults()
+	}
+}
+
+// operationRetryPolicy 返回工具使用的重试策略
+func (a *OpenAPIToMCPAdapter) operationRetryPolicy(toolName string) *RetryPolicy {
+	if p, ok := a.operationRetries[toolName]; ok {
+		return p
+	}
+	return a.retryPolicy
+}
+
+// withDefaults 填充重试策略的默认值
+func (p RetryPolicy) withDefaults() *RetryPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	switch {
+	case p.Jitter == 0:
+		p.Jitter = 0.2
+	case p.Jitter < 0:
+		p.Jitter = 0
+	case p.Jitter > 1:
+		p.Jitter = 1
+	}
+	if p.RetryableStatus == nil {
+		p.RetryableStatus = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	return &p
+}
+
+// isIdempotent 判断 HTTP 方法是否幂等
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// attempts 返回该方法允许的最大尝试次数
+func (p *RetryPolicy) attempts(method string) int {
+	if p == nil || p.MaxAttempts <= 1 {
+		return 1
+	}
+	if isIdempotent(method) || p.RetryNonIdempotent || p.IdempotencyKeyHeader != "" {
+		return p.MaxAttempts
+	}
+	return 1
+}
+
+// retryable 判断本次结果是否值得重试
+func (p *RetryPolicy) retryable(ctx context.Context, resp *backendResponse, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	for _, status := range p.RetryableStatus {
+		if resp.statusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff 计算第 attempt 次失败后的等待时间，优先遵循 Retry-After
+func (p *RetryPolicy) backoff(attempt int, resp *backendResponse) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+	}
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	d -= d * p.Jitter * mathrand.Float64()
+	return time.Duration(d)
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期形式的 Retry-After
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// newIdempotencyKey 生成随机幂等键
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// defaultRetryPolicy 用于未配置重试策略时判断是否需要故障转移
+var defaultRetryPolicy = RetryPolicy{}.withDefaults()
+
+// callBackend 按重试策略调用后端，header 是附加的请求头，返回最后一次尝试的结果。
+// 配置了多个后端实例时，幂等请求失败后会立即转移到尚未尝试过的实例，不占用重试次数。
+func (a *OpenAPIToMCPAdapter) callBackend(ctx context.Context, op toolOperation, path string, body []byte, header http.Header) (*backendResponse, error) {
+	policy := op.retry
+	maxAttempts := policy.attempts(op.method)
+	if policy == nil {
+		policy = defaultRetryPolicy
+	}
+
+	var idempotencyKey string
+	if maxAttempts > 1 && policy.IdempotencyKeyHeader != "" && !isIdempotent(op.method) {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	endpoints := a.endpoints()
+	tried := make(map[*endpoint]bool)
+	retries := 1
+	var last *backendResponse
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		ep := endpoints.pick(tried)
+		if ep == nil {
+			return nil, errors.New("no backend endpoint available")
+		}
+		tried[ep] = true
+
+		// 负载均衡只在所有实例的断路器都打开时才会选中断路器打开的实例
+		breaker := a.endpointBreaker(ep.url)
+		if breaker != nil {
+			if ok, wait := breaker.allow(); !ok {
+				if last != nil || lastErr != nil {
+					return last, lastErr
+				}
+				return nil, &breakerOpenError{reason: breaker.rejection(wait)}
+			}
+		}
+
+		req, err := a.newBackendRequest(ctx, op.method, ep.url+path, body)
+		if err != nil {
+			if breaker != nil {
+				breaker.abort()
+			}
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if idempotencyKey != "" {
+			req.Header.Set(policy.IdempotencyKeyHeader, idempotencyKey)
+		}
+
+		atomic.AddInt32(&ep.inflight, 1)
+		resp, err := a.doBackend(req)
+		atomic.AddInt32(&ep.inflight, -1)
+		if resp != nil {
+			resp.attempts = attempt
+		}
+		last, lastErr = resp, err
+
+		failed := policy.retryable(ctx, resp, err)
+		if ctx.Err() == nil {
+			endpoints.report(ep, !failed)
+		}
+		if breaker != nil {
+			// 客户端主动取消的调用不代表后端故障
+			if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+				breaker.abort()
+			} else {
+				breaker.record(err == nil && resp.statusCode < http.StatusInternalServerError)
+			}
+		}
+		if !failed {
+			return resp, err
+		}
+		if isIdempotent(op.method) && endpoints.hasUntried(tried) {
+			a.log().Printf("fail over %s from %s", op.toolName, ep.url)
+			continue
+		}
+		if retries >= maxAttempts {
+			return resp, err
+		}
+		retries++
+
+		wait := policy.backoff(retries-1, resp)
+		if wait > policy.MaxBackoff {
+			a.log().Printf("not retrying %s: backend asked to retry after %s", op.toolName, wait)
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+		if err != nil {
+			a.log().Printf("retry %s in %s after attempt %d: %v", op.toolName, wait, attempt, err)
+		} else {
+			a.log().Printf("retry %s in %s after attempt %d: status %d", op.toolName, wait, attempt, resp.statusCode)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
+		// 退避之后所有实例重新参与选择
+		tried = make(map[*endpoint]bool)
+	}
+}
@@ -0,0 +1,111 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryTestAdapter(t *testing.T, backendURL string, opts ...Option) *OpenAPIToMCPAdapter {
+	adapter, err := NewOpenAPIToMCPAdapter("test", "1.0.0", backendURL, "localhost:0", opts...)
+	assert.NoError(t, err)
+	adapter.openAPI = map[string]interface{}{
+		"paths": map[string]interface{}{
+			"/items": map[string]interface{}{
+				"get":  map[string]interface{}{"summary": "list"},
+				"post": map[string]interface{}{"summary": "create"},
+			},
+		},
+	}
+	assert.NoError(t, adapter.GenerateTools())
+	return adapter
+}
+
+func TestRetry_IdempotentWithBackoff(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	adapter := newRetryTestAdapter(t, backend.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+	result, err := adapter.handlers["_items_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, 3, result.Meta["attempts"])
+
+	atomic.StoreInt32(&calls, 0)
+	result, err = adapter.handlers["_items_post"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, 1, result.Meta["attempts"])
+}
+
+func TestRetry_PostWithIdempotencyKey(t *testing.T) {
+	var calls int32
+	var keys []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("created"))
+	}))
+	defer backend.Close()
+
+	adapter := newRetryTestAdapter(t, backend.URL, WithOperationRetryPolicy("_items_post", RetryPolicy{
+		MaxAttempts:          2,
+		InitialBackoff:       time.Millisecond,
+		IdempotencyKeyHeader: "Idempotency-Key",
+	}))
+
+	result, err := adapter.handlers["_items_post"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "created", result.Content[0].(mcp.TextContent).Text)
+	assert.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Jitter: -1}.withDefaults()
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1, nil))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2, nil))
+	assert.Equal(t, 300*time.Millisecond, p.backoff(5, nil))
+
+	resp := &backendResponse{header: http.Header{"Retry-After": []string{"2"}}}
+	assert.Equal(t, 2*time.Second, p.backoff(1, resp))
+}
+
+func TestRetryPolicy_LongRetryAfter(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("maintenance"))
+	}))
+	defer backend.Close()
+
+	// Retry-After 超过 MaxBackoff 时直接返回后端的响应，而不是阻塞一个小时
+	adapter := newIdentityTestAdapter(t, backend.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+	start := time.Now()
+	result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "maintenance", result.Content[0].(mcp.TextContent).Text)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Less(t, time.Since(start), time.Second)
+}
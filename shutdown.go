@@ -0,0 +1,222 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WithShutdownGracePeriod 设置关闭时等待进行中工具调用完成的最长时间，默认 30s
+func WithShutdownGracePeriod(d time.Duration) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.gracePeriod = d
+	}
+}
+
+// AbortedCall 是关闭时因超过宽限期而被取消的工具调用
+type AbortedCall struct {
+	Tool    string        `json:"tool"`
+	Session string        `json:"session,omitempty"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// ShutdownSummary 是一次关闭的结果
+type ShutdownSummary struct {
+	// Sessions 收到关闭通知的会话数
+	Sessions int `json:"sessions"`
+	// Drained 在宽限期内正常完成的调用数
+	Drained int `json:"drained"`
+	// Aborted 宽限期结束后被取消的调用
+	Aborted  []AbortedCall `json:"aborted,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// inflightCall 是一个进行中的工具调用
+type inflightCall struct {
+	toolName string
+	session  string
+	started  time.Time
+	cancel   context.CancelFunc
+}
+
+// registerCall 登记进行中的工具调用，返回注销函数
+func (a *OpenAPIToMCPAdapter) registerCall(ctx context.Context, toolName string, cancel context.CancelFunc) func() {
+	call := &inflightCall{toolName: toolName, started: time.Now(), cancel: cancel}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		call.session = session.SessionID()
+	}
+
+	a.callsMu.Lock()
+	if a.calls == nil {
+		a.calls = make(map[*inflightCall]struct{})
+	}
+	a.calls[call] = struct{}{}
+	a.callsMu.Unlock()
+
+	return func() {
+		a.callsMu.Lock()
+		delete(a.calls, call)
+		if len(a.calls) == 0 && a.callsIdle != nil {
+			close(a.callsIdle)
+			a.callsIdle = nil
+		}
+		a.callsMu.Unlock()
+	}
+}
+
+// waitCalls 等待所有进行中的调用结束，ctx 先结束时返回 false
+func (a *OpenAPIToMCPAdapter) waitCalls(ctx context.Context) bool {
+	a.callsMu.Lock()
+	if len(a.calls) == 0 {
+		a.callsMu.Unlock()
+		return true
+	}
+	if a.callsIdle == nil {
+		a.callsIdle = make(chan struct{})
+	}
+	idle := a.callsIdle
+	a.callsMu.Unlock()
+
+	select {
+	case <-idle:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// abortCalls 取消所有进行中的调用
+func (a *OpenAPIToMCPAdapter) abortCalls() []AbortedCall {
+	a.callsMu.Lock()
+	defer a.callsMu.Unlock()
+	aborted := make([]AbortedCall, 0, len(a.calls))
+	for call := range a.calls {
+		call.cancel()
+		aborted = append(aborted, AbortedCall{Tool: call.toolName, Session: call.session, Elapsed: time.Since(call.started)})
+	}
+	return aborted
+}
+
+// forgetSession 清理已结束会话的记录，包括认证提供者按会话缓存的令牌
+func (a *OpenAPIToMCPAdapter) forgetSession(sessionID string) {
+	a.sessions.Delete(sessionID)
+	a.identities.Delete(sessionID)
+	a.forgetAuthSession(sessionID)
+}
+
+// forgetAuthSession 清理认证提供者按会话缓存的状态，合并进来的适配器的会话由当前适配器管理，一并清理
+func (a *OpenAPIToMCPAdapter) forgetAuthSession(sessionID string) {
+	if cache, ok := a.authProvider.(sessionCache); ok {
+		cache.forgetSession(sessionID)
+	}
+	a.membersMu.Lock()
+	members := append([]*OpenAPIToMCPAdapter{}, a.members...)
+	a.membersMu.Unlock()
+	for _, m := range members {
+		m.forgetAuthSession(sessionID)
+	}
+}
+
+// notifySessions 向所有已初始化的会话发送日志通知，返回通知到的会话数
+func (a *OpenAPIToMCPAdapter) notifySessions(level, message string) int {
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: "notifications/message",
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]interface{}{
+					"level":  level,
+					"logger": "go-mcp-adapter",
+					"data":   message,
+				},
+			},
+		},
+	}
+
+	notified := 0
+	a.sessions.Range(func(_, value interface{}) bool {
+		session := value.(server.ClientSession)
+		if !session.Initialized() {
+			return true
+		}
+		select {
+		case session.NotificationChannel() <- notification:
+			notified++
+		default:
+		}
+		return true
+	})
+	return notified
+}
+
+// rejectNewSession 在关闭过程中拒绝建立新会话，返回是否已拒绝
+func (a *OpenAPIToMCPAdapter) rejectNewSession(w http.ResponseWriter) bool {
+	if !a.draining.Load() {
+		return false
+	}
+	http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+	return true
+}
+
+// Shutdown 优雅关闭适配器：停止接受新会话和新调用，通知已连接的客户端，
+// 等待进行中的工具调用完成，超过宽限期或 ctx 结束后取消剩余的后端请求，最后关闭所有传输。
+// Mount 挂载的适配器同时关闭，结果合并到返回的摘要中。重复调用返回第一次关闭的结果。
+func (a *OpenAPIToMCPAdapter) Shutdown(ctx context.Context) (ShutdownSummary, error) {
+	a.shutdownOnce.Do(func() {
+		a.shutdownSummary, a.shutdownErr = a.shutdown(ctx)
+	})
+	return a.shutdownSummary, a.shutdownErr
+}
+
+// shutdown 执行关闭流程
+func (a *OpenAPIToMCPAdapter) shutdown(ctx context.Context) (ShutdownSummary, error) {
+	start := time.Now()
+	a.draining.Store(true)
+
+	summary := ShutdownSummary{Sessions: a.notifySessions("warning", "server is shutting down")}
+	a.callsMu.Lock()
+	inflight := len(a.calls)
+	a.callsMu.Unlock()
+
+	grace := a.gracePeriod
+	if grace <= 0 {
+		grace = 30 * time.Second
+	}
+	mounted := make(chan ShutdownSummary, 1)
+	go func() { mounted <- a.shutdownMounted(ctx) }()
+	waitCtx, cancel := context.WithTimeout(ctx, grace)
+	drained := a.waitCalls(waitCtx)
+	cancel()
+	if !drained {
+		summary.Aborted = a.abortCalls()
+		// 给被取消的调用一点时间返回结果
+		exitCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		a.waitCalls(exitCtx)
+		cancel()
+	}
+	if summary.Drained = inflight - len(summary.Aborted); summary.Drained < 0 {
+		summary.Drained = 0
+	}
+	m := <-mounted
+	summary.Sessions += m.Sessions
+	summary.Drained += m.Drained
+	summary.Aborted = append(summary.Aborted, m.Aborted...)
+
+	a.shutdownHandler(ctx)
+	a.stopMu.Lock()
+	if a.stopServing != nil {
+		a.stopServing()
+	}
+	a.stopMu.Unlock()
+
+	summary.Duration = time.Since(start)
+	a.log().Info().Msgf("mcp adapter shut down in %s: %d sessions notified, %d calls drained, %d aborted",
+		summary.Duration.Round(time.Millisecond), summary.Sessions, summary.Drained, len(summary.Aborted))
+	for _, call := range summary.Aborted {
+		a.log().Printf("aborted tool call %s (session %s) after %s", call.Tool, call.Session, call.Elapsed.Round(time.Millisecond))
+	}
+	return summary, ctx.Err()
+}
@@ -0,0 +1,122 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown_DrainsInflightCalls(t *testing.T) {
+	started := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	}))
+	defer backend.Close()
+
+	adapter := newSlowTestAdapter(t, backend.URL, map[string]interface{}{"summary": "slow"})
+	session := newChannelSession()
+	session.Initialize()
+	assert.NoError(t, adapter.server.RegisterSession(context.Background(), session))
+
+	results := make(chan *mcp.CallToolResult)
+	go func() {
+		result, _ := adapter.handlers["_slow_get"](context.Background(), mcp.CallToolRequest{})
+		results <- result
+	}()
+	<-started
+
+	summary, err := adapter.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Sessions)
+	assert.Equal(t, 1, summary.Drained)
+	assert.Empty(t, summary.Aborted)
+
+	result := <-results
+	assert.False(t, result.IsError)
+	notification := <-session.notifications
+	assert.Equal(t, "notifications/message", notification.Method)
+	assert.Equal(t, "warning", notification.Params.AdditionalFields["level"])
+
+	// 关闭后不再接受新的调用和会话
+	result, err = adapter.handlers["_slow_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "draining", adapter.Health().Status)
+
+	rec := httptest.NewRecorder()
+	adapter.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	rec = httptest.NewRecorder()
+	adapter.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	again, err := adapter.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, summary, again)
+}
+
+func TestShutdown_AbortsCallsAfterGracePeriod(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer backend.Close()
+
+	adapter := newSlowTestAdapter(t, backend.URL, map[string]interface{}{"summary": "slow"},
+		WithShutdownGracePeriod(50*time.Millisecond))
+	ctx := adapter.server.WithContext(context.Background(), &fakeSession{id: "s1"})
+
+	errs := make(chan error)
+	go func() {
+		_, err := adapter.handlers["_slow_get"](ctx, mcp.CallToolRequest{})
+		errs <- err
+	}()
+	<-started
+
+	summary, err := adapter.Shutdown(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, summary.Drained)
+	if assert.Len(t, summary.Aborted, 1) {
+		assert.Equal(t, "_slow_get", summary.Aborted[0].Tool)
+		assert.Equal(t, "s1", summary.Aborted[0].Session)
+		assert.GreaterOrEqual(t, summary.Aborted[0].Elapsed, 50*time.Millisecond)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("backend request was not cancelled")
+	}
+	assert.Error(t, <-errs)
+}
+
+func TestShutdown_ContextDeadline(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer backend.Close()
+
+	adapter := newSlowTestAdapter(t, backend.URL, map[string]interface{}{"summary": "slow"})
+	go adapter.handlers["_slow_get"](context.Background(), mcp.CallToolRequest{})
+	assert.Eventually(t, func() bool {
+		adapter.callsMu.Lock()
+		defer adapter.callsMu.Unlock()
+		return len(adapter.calls) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	summary, err := adapter.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, summary.Aborted, 1)
+}
@@ -0,0 +1,337 @@
+package gmadapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOpenAPIReader 从 r 读取 OpenAPI 文档，JSON 和 YAML 格式均可；这样加载的文档不支持热加载
+func (a *OpenAPIToMCPAdapter) LoadOpenAPIReader(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return a.LoadOpenAPIBytes(data)
+}
+
+// LoadOpenAPIBytes 从内存中加载 OpenAPI 文档，JSON 和 YAML 格式均可；这样加载的文档不支持热加载
+func (a *OpenAPIToMCPAdapter) LoadOpenAPIBytes(data []byte) error {
+	return a.loadSpec(data, specVersion{digest: sha256.Sum256(data)})
+}
+
+// LoadOpenAPIFS 从 fsys 中的文件加载 OpenAPI 文档，例如通过 embed.FS 打包进二进制的文档，
+// name 是 fs.FS 形式的路径（以 / 分隔，不以 / 开头）。开启 WithSpecReload 时按间隔检查文件是否变化。
+func (a *OpenAPIToMCPAdapter) LoadOpenAPIFS(fsys fs.FS, name string) error {
+	if !fs.ValidPath(name) {
+		return fmt.Errorf("invalid OpenAPI path %q in file system", name)
+	}
+	data, version, err := a.fetchSpec(context.Background(), specVersion{source: name, path: name, fsys: fsys})
+	if err != nil {
+		return err
+	}
+	return a.loadSpec(data, version)
+}
+
+// loadSpec 解析文档并记录它的来源，远程文档同时写入磁盘缓存
+func (a *OpenAPIToMCPAdapter) loadSpec(data []byte, version specVersion) error {
+	openAPI, err := parseSpec(data)
+	if err != nil {
+		return err
+	}
+	a.saveSpecCache(version, data)
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+	a.openAPI = openAPI
+	a.spec = version
+	return nil
+}
+
+// specVersion 记录已加载文档的来源和版本，用于热加载时读取文档并判断文档是否变化
+type specVersion struct {
+	// source 是加载时传入的来源，用于日志
+	source string
+	// url 和 path 二选一，fsys 非空时 path 是其中的文件
+	url  string
+	path string
+	fsys fs.FS
+
+	etag         string
+	lastModified string
+	digest       [sha256.Size]byte
+	// fetched 表示文档是这次从文档服务器获取的，而不是来自缓存
+	fetched bool
+}
+
+// reloadable 判断文档是否可以重新读取
+func (v specVersion) reloadable() bool {
+	return v.url != "" || v.path != ""
+}
+
+// specLocation 解析文档来源：http 和 https 地址按 URL 读取，file:// 地址和其他字符串按本地路径读取
+func specLocation(source string) (specVersion, error) {
+	version := specVersion{source: source, path: source}
+	u, err := url.Parse(source)
+	if err != nil {
+		return version, nil
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return version, fmt.Errorf("invalid OpenAPI URL %q: missing host", source)
+		}
+		version.url, version.path = source, ""
+	case "file":
+		if u.Host != "" && u.Host != "localhost" {
+			return version, fmt.Errorf("invalid OpenAPI URL %q: remote file hosts are not supported", source)
+		}
+		version.path = filepath.FromSlash(u.Path)
+	}
+	return version, nil
+}
+
+// fetchSpec 按 from 的来源读取文档，内容与 from 相同时返回 errUnchanged。
+// URL 来源带上 from 的 ETag 和 Last-Modified 发起条件请求；首次加载时如果配置了 CacheDir，
+// 用缓存的版本发起条件请求，文档服务器不可用时使用缓存的文档。
+func (a *OpenAPIToMCPAdapter) fetchSpec(ctx context.Context, from specVersion) ([]byte, specVersion, error) {
+	version := specVersion{source: from.source, url: from.url, path: from.path, fsys: from.fsys}
+	var data []byte
+	var err error
+	switch {
+	case from.url != "":
+		cfg := a.specFetch()
+		var cached *specCacheEntry
+		if from.digest == (specVersion{}).digest {
+			if cached = readSpecCache(cfg.CacheDir, from.url); cached != nil {
+				from.etag, from.lastModified = cached.ETag, cached.LastModified
+			}
+		}
+		data, version.etag, version.lastModified, err = requestSpec(ctx, cfg, from)
+		switch {
+		case err == nil:
+			version.fetched = true
+		case cached != nil:
+			if err != errUnchanged {
+				a.log().Printf("fetch OpenAPI spec %s: %v; using the cached copy from %s", from.url, err, cached.FetchedAt.Format(time.RFC3339))
+			}
+			data, version.etag, version.lastModified = cached.Data, cached.ETag, cached.LastModified
+		default:
+			return nil, from, err
+		}
+	case from.fsys != nil:
+		if data, err = fs.ReadFile(from.fsys, from.path); err != nil {
+			return nil, from, err
+		}
+	default:
+		if data, err = ioutil.ReadFile(from.path); err != nil {
+			return nil, from, err
+		}
+	}
+
+	version.digest = sha256.Sum256(data)
+	if version.digest == from.digest {
+		return nil, version, errUnchanged
+	}
+	return data, version, nil
+}
+
+// requestSpec 请求远程文档，带上 from 的 ETag 和 Last-Modified 发起条件请求，未修改时返回 errUnchanged
+func requestSpec(ctx context.Context, cfg SpecFetchConfig, from specVersion) (data []byte, etag, lastModified string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, from.url, nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.8")
+	for name, value := range cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	if cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.BearerToken)
+	}
+	if from.etag != "" {
+		req.Header.Set("If-None-Match", from.etag)
+	}
+	if from.lastModified != "" {
+		req.Header.Set("If-Modified-Since", from.lastModified)
+	}
+
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, "", "", errUnchanged
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", "", fmt.Errorf("fetch %s: unexpected status %s", from.url, resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+		return nil, "", "", fmt.Errorf("fetch %s: response looks like HTML, not an OpenAPI document", from.url)
+	}
+	if data, err = ioutil.ReadAll(io.LimitReader(resp.Body, cfg.MaxBytes+1)); err != nil {
+		return nil, "", "", fmt.Errorf("fetch %s: %w", from.url, err)
+	}
+	if int64(len(data)) > cfg.MaxBytes {
+		return nil, "", "", fmt.Errorf("fetch %s: spec is larger than %d bytes; raise max_bytes if this is expected", from.url, cfg.MaxBytes)
+	}
+	return data, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+}
+
+// SpecFetchConfig 是获取远程 OpenAPI 文档的配置，与访问后端的配置相互独立
+type SpecFetchConfig struct {
+	// Headers 请求文档时附加的请求头
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// BearerToken 非空时通过 Authorization: Bearer 发送
+	BearerToken string `json:"bearer_token,omitempty" yaml:"bearer_token,omitempty"`
+	// Timeout 单次获取的超时时间，默认 30s
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// MaxBytes 文档大小上限，默认 32MiB，超过时加载失败
+	MaxBytes int64 `json:"max_bytes,omitempty" yaml:"max_bytes,omitempty"`
+	// HTTPClient 获取文档使用的 TLS、mTLS 和代理等配置
+	HTTPClient *HTTPClientConfig `json:"http_client,omitempty" yaml:"http_client,omitempty"`
+	// CacheDir 非空时把成功加载的文档保存在该目录下，启动时文档服务器不可用则加载上一次的文档
+	CacheDir string `json:"cache_dir,omitempty" yaml:"cache_dir,omitempty"`
+	// Client 获取文档使用的客户端，优先于 HTTPClient
+	Client *http.Client `json:"-" yaml:"-"`
+}
+
+// WithSpecFetch 设置 LoadOpenAPI 获取远程文档时使用的请求头、认证、TLS、超时和磁盘缓存
+func WithSpecFetch(cfg SpecFetchConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.specFetchConfig = &cfg
+	}
+}
+
+// specFetch 返回获取远程文档的配置，未设置的字段使用默认值
+func (a *OpenAPIToMCPAdapter) specFetch() SpecFetchConfig {
+	var cfg SpecFetchConfig
+	if a.specFetchConfig != nil {
+		cfg = *a.specFetchConfig
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 32 << 20
+	}
+	if cfg.Client == nil {
+		cfg.Client = defaultHTTPClient
+	}
+	return cfg
+}
+
+// specCacheEntry 是磁盘缓存中的一份远程文档
+type specCacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Data         []byte    `json:"data"`
+}
+
+// specCachePath 返回 URL 对应的缓存文件，URL 中可能带有凭据，文件名使用它的哈希
+func specCachePath(dir, rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readSpecCache 读取 URL 对应的缓存，没有缓存或缓存损坏时返回 nil
+func readSpecCache(dir, rawURL string) *specCacheEntry {
+	if dir == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(specCachePath(dir, rawURL))
+	if err != nil {
+		return nil
+	}
+	var entry specCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != rawURL {
+		return nil
+	}
+	return &entry
+}
+
+// writeSpecCache 保存文档，先写临时文件再重命名，避免进程中断留下不完整的缓存
+func writeSpecCache(dir string, version specVersion, data []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	content, err := json.Marshal(specCacheEntry{
+		URL:          version.url,
+		ETag:         version.etag,
+		LastModified: version.lastModified,
+		FetchedAt:    time.Now().UTC(),
+		Data:         data,
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".spec-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), specCachePath(dir, version.url))
+}
+
+// saveSpecCache 把成功解析的远程文档保存到磁盘缓存，失败时只记录日志
+func (a *OpenAPIToMCPAdapter) saveSpecCache(version specVersion, data []byte) {
+	dir := a.specFetch().CacheDir
+	if !version.fetched || dir == "" {
+		return
+	}
+	if err := writeSpecCache(dir, version, data); err != nil {
+		a.log().Printf("cache OpenAPI spec %s: %v", version.url, err)
+	}
+}
+
+// parseSpec 解析 OpenAPI 文档：以 { 开头的内容按 JSON 解析，其余按 YAML 解析
+func parseSpec(data []byte) (map[string]interface{}, error) {
+	content := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	var openAPI map[string]interface{}
+	switch {
+	case len(content) == 0:
+		return nil, errors.New("the OpenAPI document is empty")
+	case content[0] == '<':
+		// 常见于返回了错误页面的文档地址
+		return nil, errors.New("the OpenAPI document looks like HTML or XML, expected JSON or YAML")
+	case content[0] == '{':
+		if err := json.Unmarshal(content, &openAPI); err != nil {
+			return nil, fmt.Errorf("parse OpenAPI JSON: %w", err)
+		}
+	default:
+		if err := yaml.Unmarshal(content, &openAPI); err != nil {
+			return nil, fmt.Errorf("parse OpenAPI YAML: %w", err)
+		}
+	}
+	if openAPI == nil {
+		return nil, errors.New("the OpenAPI document is empty")
+	}
+	return openAPI, nil
+}
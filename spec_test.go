@@ -0,0 +1,179 @@
+package gmadapter
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecLocation(t *testing.T) {
+	for source, want := range map[string]specVersion{
+		"http-api.yaml":                  {source: "http-api.yaml", path: "http-api.yaml"},
+		"./specs/openapi.json":           {source: "./specs/openapi.json", path: "./specs/openapi.json"},
+		"https://api.example.com/v3.yml": {source: "https://api.example.com/v3.yml", url: "https://api.example.com/v3.yml"},
+		"HTTP://api.example.com/spec":    {source: "HTTP://api.example.com/spec", url: "HTTP://api.example.com/spec"},
+		"file:///etc/specs/openapi.yaml": {source: "file:///etc/specs/openapi.yaml", path: filepath.FromSlash("/etc/specs/openapi.yaml")},
+	} {
+		got, err := specLocation(source)
+		assert.NoError(t, err, source)
+		assert.Equal(t, want, got, source)
+	}
+
+	_, err := specLocation("https:///openapi.yaml")
+	assert.ErrorContains(t, err, "missing host")
+	_, err = specLocation("file://fileserver/openapi.yaml")
+	assert.ErrorContains(t, err, "remote file hosts")
+}
+
+func TestParseSpec(t *testing.T) {
+	// JSON 中的制表符缩进不是合法的 YAML 缩进
+	doc, err := parseSpec([]byte("\xef\xbb\xbf\n{\n\t\"paths\": {\"/users\": {\"get\": {\"summary\": \"List users\"}}}\n}"))
+	assert.NoError(t, err)
+	assert.Contains(t, doc["paths"], "/users")
+
+	doc, err = parseSpec([]byte(reloadSpecV1))
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.0", doc["openapi"])
+
+	_, err = parseSpec([]byte(`{"paths": `))
+	assert.ErrorContains(t, err, "parse OpenAPI JSON")
+	_, err = parseSpec([]byte("paths: ["))
+	assert.ErrorContains(t, err, "parse OpenAPI YAML")
+	_, err = parseSpec([]byte("<!DOCTYPE html><html><body>Not Found</body></html>"))
+	assert.ErrorContains(t, err, "looks like HTML")
+	_, err = parseSpec([]byte("  \n"))
+	assert.ErrorContains(t, err, "empty")
+}
+
+func TestLoadOpenAPI_Sources(t *testing.T) {
+	newAdapter := func() *OpenAPIToMCPAdapter {
+		a, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0")
+		assert.NoError(t, err)
+		return a
+	}
+	toolNames := func(a *OpenAPIToMCPAdapter) []string {
+		assert.NoError(t, a.GenerateTools())
+		var names []string
+		for _, info := range a.Tools() {
+			names = append(names, info.Name)
+		}
+		return names
+	}
+
+	a := newAdapter()
+	assert.NoError(t, a.LoadOpenAPIReader(strings.NewReader(`{"paths": {"/users": {"get": {}}}}`)))
+	assert.Equal(t, []string{"_users_get"}, toolNames(a))
+	_, err := a.Reload(context.Background())
+	assert.ErrorContains(t, err, "not loaded from a file or URL")
+
+	a = newAdapter()
+	assert.NoError(t, a.LoadOpenAPIBytes([]byte(reloadSpecV1)))
+	assert.Equal(t, []string{"_orders_get", "_users_get"}, toolNames(a))
+
+	spec := filepath.Join(t.TempDir(), "http-api.yaml")
+	assert.NoError(t, ioutil.WriteFile(spec, []byte(reloadSpecV1), 0644))
+	a = newAdapter()
+	assert.NoError(t, a.LoadOpenAPI("file://"+filepath.ToSlash(spec)))
+	assert.Equal(t, []string{"_orders_get", "_users_get"}, toolNames(a))
+
+	// fs.FS 中的文档可以热加载
+	fsys := fstest.MapFS{"specs/openapi.yaml": {Data: []byte(reloadSpecV1)}}
+	a = newAdapter()
+	assert.ErrorContains(t, a.LoadOpenAPIFS(fsys, "/specs/openapi.yaml"), "invalid OpenAPI path")
+	assert.NoError(t, a.LoadOpenAPIFS(fsys, "specs/openapi.yaml"))
+	assert.Equal(t, []string{"_orders_get", "_users_get"}, toolNames(a))
+	fsys["specs/openapi.yaml"] = &fstest.MapFile{Data: []byte(reloadSpecV2)}
+	result, err := a.Reload(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"_invoices_get"}, result.Added)
+
+	// 返回错误页面的 URL 不会被当作文档解析
+	html := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>maintenance</html>"))
+	}))
+	defer html.Close()
+	assert.ErrorContains(t, newAdapter().LoadOpenAPI(html.URL), "looks like HTML")
+}
+
+func TestLoadOpenAPI_SpecFetch(t *testing.T) {
+	var requests int32
+	var status int32 = http.StatusOK
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+		w.Write([]byte(reloadSpecV1))
+	}))
+	defer specServer.Close()
+
+	cacheDir := t.TempDir()
+	newAdapter := func(cfg SpecFetchConfig) *OpenAPIToMCPAdapter {
+		a, err := NewOpenAPIToMCPAdapter("test", "1.0.0", "http://backend", "localhost:0", WithSpecFetch(cfg))
+		assert.NoError(t, err)
+		return a
+	}
+	cfg := SpecFetchConfig{Headers: map[string]string{"X-Tenant": "acme"}, BearerToken: "secret", CacheDir: cacheDir}
+
+	assert.ErrorContains(t, newAdapter(SpecFetchConfig{}).LoadOpenAPI(specServer.URL), "unexpected status 401")
+
+	atomic.StoreInt32(&status, http.StatusNotFound)
+	assert.ErrorContains(t, newAdapter(cfg).LoadOpenAPI(specServer.URL), "unexpected status 404")
+	atomic.StoreInt32(&status, http.StatusOK)
+
+	a := newAdapter(cfg)
+	assert.NoError(t, a.LoadOpenAPI(specServer.URL))
+	assert.NoError(t, a.GenerateTools())
+	assert.Len(t, a.Tools(), 2)
+
+	// 新的适配器用缓存的 ETag 发起条件请求，304 时使用缓存的文档
+	a = newAdapter(cfg)
+	assert.NoError(t, a.LoadOpenAPI(specServer.URL))
+	assert.NoError(t, a.GenerateTools())
+	assert.Len(t, a.Tools(), 2)
+
+	// 文档服务器不可用时从缓存启动
+	specServer.Close()
+	a = newAdapter(cfg)
+	assert.NoError(t, a.LoadOpenAPI(specServer.URL))
+	assert.NoError(t, a.GenerateTools())
+	assert.Len(t, a.Tools(), 2)
+	assert.Error(t, newAdapter(SpecFetchConfig{BearerToken: "secret"}).LoadOpenAPI(specServer.URL))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	// 超时的请求被取消
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	start := time.Now()
+	assert.ErrorIs(t, newAdapter(SpecFetchConfig{Timeout: 50 * time.Millisecond}).LoadOpenAPI(slow.URL), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// 超过大小上限的文档不会被完整读入内存
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(reloadSpecV1))
+	}))
+	defer large.Close()
+	assert.ErrorContains(t, newAdapter(SpecFetchConfig{MaxBytes: 64}).LoadOpenAPI(large.URL), "spec is larger than 64 bytes")
+	assert.NoError(t, newAdapter(SpecFetchConfig{MaxBytes: int64(len(reloadSpecV1))}).LoadOpenAPI(large.URL))
+}
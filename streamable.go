@@ -0,0 +1,290 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sessionIDHeader 是 streamable HTTP 传输中携带会话 ID 的请求头
+const sessionIDHeader = "Mcp-Session-Id"
+
+// streamableSessionIdle 没有活动的 streamable HTTP 会话多久后被清理
+const streamableSessionIdle = 30 * time.Minute
+
+// streamableSweepInterval 检查闲置会话的间隔
+const streamableSweepInterval = time.Minute
+
+// streamableMaxMessage 单次 POST 请求体的大小上限，与 WebSocket 默认的单条消息上限一致
+const streamableMaxMessage = 10 << 20
+
+// streamableServer 是 MCP streamable HTTP 传输（2025-03-26 规范）的最小实现：
+// POST 提交 JSON-RPC 消息或批量消息并直接以 JSON 返回响应，
+// GET 打开 SSE 流接收服务端通知，DELETE 结束会话。
+type streamableServer struct {
+	adapter *OpenAPIToMCPAdapter
+
+	mu       sync.Mutex
+	sessions map[string]*channelSession
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newStreamableServer 创建 streamable HTTP 传输，并定期清理闲置的会话直到 shutdown
+func newStreamableServer(a *OpenAPIToMCPAdapter) *streamableServer {
+	s := &streamableServer{adapter: a, sessions: make(map[string]*channelSession), stop: make(chan struct{})}
+	go s.sweep(streamableSweepInterval, streamableSessionIdle)
+	return s
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *streamableServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleGet(w, r)
+	case http.MethodDelete:
+		session, ok := s.session(w, r)
+		if !ok {
+			return
+		}
+		s.remove(session)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost 处理客户端提交的 JSON-RPC 消息，initialize 请求会创建新会话
+func (s *streamableServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, streamableMaxMessage))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	messages, batch, err := parseMessages(data)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, createErrorResponse(nil, mcp.PARSE_ERROR, "Parse error"))
+		return
+	}
+
+	var session *channelSession
+	if r.Header.Get(sessionIDHeader) == "" && isInitialize(messages) {
+		if s.adapter.rejectNewSession(w) {
+			return
+		}
+		session, err = s.create(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(sessionIDHeader, session.id)
+	} else {
+		var ok bool
+		if session, ok = s.session(w, r); !ok {
+			return
+		}
+	}
+	session.touch()
+
+	a := s.adapter
+	ctx := a.sseContextFunc(a.server.WithContext(r.Context(), session), r)
+	var responses []mcp.JSONRPCMessage
+	for _, message := range messages {
+		if response := a.server.HandleMessage(ctx, message); response != nil {
+			responses = append(responses, response)
+		}
+	}
+
+	switch {
+	case len(responses) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case batch:
+		writeJSON(w, http.StatusOK, responses)
+	default:
+		writeJSON(w, http.StatusOK, responses[0])
+	}
+}
+
+// handleGet 打开 SSE 流，把服务端通知推送给客户端，直到连接断开或会话结束
+func (s *streamableServer) handleGet(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case notification := <-session.notifications:
+			data, err := json.Marshal(notification)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+			session.touch()
+		case <-session.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// sweep 每隔 interval 清理闲置超过 idle 的会话，直到 shutdown
+func (s *streamableServer) sweep(interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.expire(idle)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// expire 结束闲置超过 idle 的会话
+func (s *streamableServer) expire(idle time.Duration) {
+	s.mu.Lock()
+	var expired []*channelSession
+	for _, session := range s.sessions {
+		if session.idleFor() > idle {
+			expired = append(expired, session)
+		}
+	}
+	s.mu.Unlock()
+	for _, session := range expired {
+		s.adapter.log().Printf("expire idle streamable http session %s", session.id)
+		s.remove(session)
+	}
+}
+
+// create 创建并注册新会话
+func (s *streamableServer) create(r *http.Request) (*channelSession, error) {
+	session := newChannelSession()
+	ctx := r.Context()
+	if len(s.adapter.capturedHeaders()) > 0 {
+		ctx = context.WithValue(ctx, identityKey{}, s.adapter.captureIdentity(r))
+	}
+	if err := s.adapter.server.RegisterSession(ctx, session); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.sessions[session.id] = session
+	s.mu.Unlock()
+	return session, nil
+}
+
+// session 根据请求头查找会话，找不到时写入错误响应
+func (s *streamableServer) session(w http.ResponseWriter, r *http.Request) (*channelSession, bool) {
+	id := r.Header.Get(sessionIDHeader)
+	if id == "" {
+		http.Error(w, "missing "+sessionIDHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return nil, false
+	}
+	return session, true
+}
+
+// remove 结束并注销会话
+func (s *streamableServer) remove(session *channelSession) {
+	s.mu.Lock()
+	delete(s.sessions, session.id)
+	s.mu.Unlock()
+	session.close()
+	s.adapter.server.UnregisterSession(session.id)
+	s.adapter.forgetSession(session.id)
+}
+
+// shutdown 停止清理闲置会话并结束所有会话
+func (s *streamableServer) shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	sessions := make([]*channelSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mu.Unlock()
+	for _, session := range sessions {
+		s.remove(session)
+	}
+	return nil
+}
+
+// parseMessages 解析单条或批量的 JSON-RPC 消息
+func parseMessages(data []byte) (messages []json.RawMessage, batch bool, err error) {
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &messages)
+		return messages, true, err
+	}
+	err = json.Unmarshal(data, new(json.RawMessage))
+	return []json.RawMessage{data}, false, err
+}
+
+// isInitialize 判断消息中是否包含 initialize 请求
+func isInitialize(messages []json.RawMessage) bool {
+	for _, message := range messages {
+		var m struct {
+			Method string `json:"method"`
+		}
+		if json.Unmarshal(message, &m) == nil && m.Method == string(mcp.MethodInitialize) {
+			return true
+		}
+	}
+	return false
+}
+
+// createErrorResponse 构造 JSON-RPC 错误响应
+func createErrorResponse(id interface{}, code int, message string) mcp.JSONRPCMessage {
+	return mcp.JSONRPCError{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      id,
+		Error: struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data,omitempty"`
+		}{Code: code, Message: message},
+	}
+}
+
+// writeJSON 以 JSON 写入响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,216 @@
+package gmadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	stdlog "log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// 传输方式
+const (
+	TransportSSE            = "sse"
+	TransportStdio          = "stdio"
+	TransportStreamableHTTP = "streamable-http"
+	TransportWebSocket      = "websocket"
+)
+
+// streamablePath 是 streamable HTTP 传输的端点路径
+const streamablePath = "/mcp"
+
+// websocketPath 是 WebSocket 传输的端点路径
+const websocketPath = "/ws"
+
+// WithTransports 选择 Start 启动的传输方式，可同时启用多个，默认只启用 SSE。
+// 除 stdio 外的传输共用同一个监听地址，streamable HTTP 的端点为 /mcp，WebSocket 的端点为 /ws，
+// 路径都在 WithBasePath 设置的基础路径之下。
+func WithTransports(transports ...string) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.transports = transports
+	}
+}
+
+// WithStdio 设置 stdio 传输的输入输出，默认使用 os.Stdin 和 os.Stdout
+func WithStdio(in io.Reader, out io.Writer) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.stdin, a.stdout = in, out
+	}
+}
+
+// channelSession 是由适配器自己管理的 MCP 会话，服务端通知写入 notifications
+type channelSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+	lastSeen      atomic.Int64
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// newChannelSession 创建会话
+func newChannelSession() *channelSession {
+	s := &channelSession{
+		id:            uuid.NewString(),
+		notifications: make(chan mcp.JSONRPCNotification, 100),
+		done:          make(chan struct{}),
+	}
+	s.touch()
+	return s
+}
+
+func (s *channelSession) SessionID() string { return s.id }
+
+func (s *channelSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *channelSession) Initialize() { s.initialized.Store(true) }
+
+func (s *channelSession) Initialized() bool { return s.initialized.Load() }
+
+// touch 记录会话最近一次活动的时间
+func (s *channelSession) touch() { s.lastSeen.Store(time.Now().UnixNano()) }
+
+// idleFor 返回会话已闲置的时长
+func (s *channelSession) idleFor() time.Duration {
+	return time.Since(time.Unix(0, s.lastSeen.Load()))
+}
+
+// close 结束会话，通知所有正在推送的流退出
+func (s *channelSession) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// enabledTransports 返回要启动的传输方式
+func (a *OpenAPIToMCPAdapter) enabledTransports() ([]string, error) {
+	if len(a.transports) == 0 {
+		return []string{TransportSSE}, nil
+	}
+	for _, t := range a.transports {
+		if !knownTransport(t) {
+			return nil, fmt.Errorf("unknown transport %q", t)
+		}
+	}
+	return a.transports, nil
+}
+
+// knownTransport 判断是否是支持的传输方式
+func knownTransport(t string) bool {
+	switch t {
+	case TransportSSE, TransportStdio, TransportStreamableHTTP, TransportWebSocket:
+		return true
+	}
+	return false
+}
+
+// serveStdio 在 stdio 上提供 MCP 服务，直到输入结束或 ctx 结束
+func (a *OpenAPIToMCPAdapter) serveStdio(ctx context.Context) error {
+	in, out := a.stdin, a.stdout
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	a.log().Info().Msg("start mcp adapter on stdio")
+	s := server.NewStdioServer(a.server)
+	s.SetErrorLogger(stdlog.New(a.log(), "", 0))
+	err := s.Listen(ctx, in, out)
+	a.forgetSession("stdio")
+	return err
+}
+
+// serveHTTP 在 a.addrs 上提供 Handler 以及指标和健康检查端点
+func (a *OpenAPIToMCPAdapter) serveHTTP(ctx context.Context, transports []string) error {
+	a.log().Info().Msgf("start mcp adapter at %s (%v)", a.addrs, transports)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", a.MetricsHandler())
+	mux.Handle("/healthz", a.HealthHandler())
+	mux.Handle("/", a.Handler())
+	srv := &http.Server{Addr: a.addrs, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		a.shutdownHandler(shutdownCtx)
+		// SSE 流只在请求上下文结束时退出，超时后强制关闭剩余的连接
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			srv.Close()
+		}
+	}()
+
+	return srv.ListenAndServe()
+}
+
+// serveTransports 同时启动所有传输方式，返回第一个非正常退出的错误。
+// ctx 结束或任一传输退出后执行 Shutdown：先等待进行中的调用，再停止所有传输。
+func (a *OpenAPIToMCPAdapter) serveTransports(parent context.Context) error {
+	transports, err := a.enabledTransports()
+	if err != nil {
+		return err
+	}
+
+	// 传输的生命周期由 Shutdown 控制，避免 ctx 结束时直接断开进行中的调用
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a.stopMu.Lock()
+	a.stopServing = cancel
+	a.stopMu.Unlock()
+
+	var serves []func(context.Context) error
+	var httpTransports []string
+	for _, t := range transports {
+		if t == TransportStdio {
+			serves = append(serves, a.serveStdio)
+		} else {
+			httpTransports = append(httpTransports, t)
+		}
+	}
+	if len(httpTransports) > 0 {
+		serves = append(serves, func(ctx context.Context) error { return a.serveHTTP(ctx, httpTransports) })
+	}
+
+	errs := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func(serve func(context.Context) error) {
+			errs <- serve(ctx)
+		}(serve)
+	}
+
+	var shutdown sync.Once
+	stop := func() {
+		shutdown.Do(func() {
+			go a.Shutdown(context.Background())
+		})
+	}
+	go func() {
+		select {
+		case <-parent.Done():
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+
+	var first error
+	for range serves {
+		err := <-errs
+		stop()
+		if first == nil && err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, context.Canceled) {
+			first = err
+		}
+	}
+	// 等待 Shutdown 完成，确保返回时关闭摘要已经输出
+	a.Shutdown(context.Background())
+	return first
+}
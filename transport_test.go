@@ -0,0 +1,161 @@
+package gmadapter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const initializeMessage = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`
+
+func TestStdioTransport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("from backend"))
+	}))
+	defer backend.Close()
+
+	in, inWriter := io.Pipe()
+	outReader, out := io.Pipe()
+	adapter := newIdentityTestAdapter(t, backend.URL, WithTransports(TransportStdio), WithStdio(in, out))
+
+	done := make(chan error, 1)
+	go func() { done <- adapter.Start(context.Background()) }()
+
+	lines := bufio.NewScanner(outReader)
+	send := func(message string) map[string]interface{} {
+		_, err := io.WriteString(inWriter, message+"\n")
+		assert.NoError(t, err)
+		assert.True(t, lines.Scan())
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(lines.Bytes(), &response))
+		return response
+	}
+
+	assert.NotNil(t, send(initializeMessage)["result"])
+	response := send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"_whoami_get","arguments":{}}}`)
+	assert.Contains(t, lines.Text(), "from backend")
+	assert.Nil(t, response["error"])
+
+	// 输入结束后 Start 正常返回
+	inWriter.Close()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after stdin closed")
+	}
+}
+
+func TestStreamableHTTPTransport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("from backend"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL)
+	adapter.registerTools()
+	handler := newStreamableServer(adapter)
+	defer handler.shutdown(context.Background())
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	post := func(sessionID, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(sessionIDHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("["+strings.Repeat(" ", streamableMaxMessage)+"]")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	resp = post("", initializeMessage)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	sessionID := resp.Header.Get(sessionIDHeader)
+	assert.NotEmpty(t, sessionID)
+
+	resp = post(sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = post(sessionID, `[{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"_whoami_get","arguments":{}}},{"jsonrpc":"2.0","id":3,"method":"ping"}]`)
+	var batch []map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&batch))
+	resp.Body.Close()
+	assert.Len(t, batch, 2)
+	data, _ := json.Marshal(batch[0])
+	assert.Contains(t, string(data), "from backend")
+
+	// 服务端通知通过 GET 打开的 SSE 流推送
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set(sessionIDHeader, sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer stream.Body.Close()
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+	adapter.server.DeleteTools("_whoami_get")
+	events := bufio.NewScanner(stream.Body)
+	for events.Scan() && !strings.HasPrefix(events.Text(), "data: ") {
+	}
+	assert.Contains(t, events.Text(), "notifications/tools/list_changed")
+
+	req, _ = http.NewRequest(http.MethodDelete, srv.URL, nil)
+	req.Header.Set(sessionIDHeader, sessionID)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = post(sessionID, `{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestStreamableHTTPTransport_ExpireIdle(t *testing.T) {
+	adapter := newIdentityTestAdapter(t, "http://backend")
+	handler := &streamableServer{adapter: adapter, sessions: make(map[string]*channelSession), stop: make(chan struct{})}
+	go handler.sweep(10*time.Millisecond, 50*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(initializeMessage)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	handler.mu.Lock()
+	session := handler.sessions[rec.Header().Get(sessionIDHeader)]
+	handler.mu.Unlock()
+	assert.NotNil(t, session)
+
+	// 没有新会话创建时闲置的会话也会被定期清理
+	assert.Eventually(t, func() bool {
+		handler.mu.Lock()
+		defer handler.mu.Unlock()
+		return len(handler.sessions) == 0
+	}, time.Second, 10*time.Millisecond)
+	<-session.done
+
+	// shutdown 后停止清理
+	assert.NoError(t, handler.shutdown(context.Background()))
+	assert.NoError(t, handler.shutdown(context.Background()))
+}
+
+func TestStart_UnknownTransport(t *testing.T) {
+	adapter := newIdentityTestAdapter(t, "http://backend", WithTransports("carrier-pigeon"))
+	assert.EqualError(t, adapter.Start(context.Background()), `unknown transport "carrier-pigeon"`)
+}
@@ -0,0 +1,231 @@
+package gmadapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WebSocketConfig 是 WebSocket 传输的配置
+type WebSocketConfig struct {
+	// PingInterval 服务端发送 ping 的间隔，默认 30s
+	PingInterval time.Duration
+	// PongTimeout 多久没有收到 pong 或任何消息后断开连接，默认 PingInterval 的两倍
+	PongTimeout time.Duration
+	// WriteTimeout 单次写消息的超时时间，默认 10s
+	WriteTimeout time.Duration
+	// MaxMessageSize 单条消息的最大字节数，默认 10MB
+	MaxMessageSize int64
+	// MaxInFlight 每个连接同时处理的消息数上限，默认 32；达到上限后暂停读取，直到有消息处理完
+	MaxInFlight int
+	// CheckOrigin 校验握手请求的 Origin，默认只允许同源或没有 Origin 的请求
+	CheckOrigin func(r *http.Request) bool
+}
+
+// WithWebSocket 设置 WebSocket 传输，需要同时通过 WithTransports 启用 websocket
+func WithWebSocket(cfg WebSocketConfig) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.websocketConfig = cfg
+	}
+}
+
+// websocketServer 通过 WebSocket 提供 MCP 服务，每个连接是一个独立会话，
+// 每条文本消息是一条 JSON-RPC 消息或批量消息
+type websocketServer struct {
+	adapter  *OpenAPIToMCPAdapter
+	cfg      WebSocketConfig
+	upgrader websocket.Upgrader
+
+	mu    sync.Mutex
+	conns map[*websocketConn]struct{}
+}
+
+// websocketConn 是一个 WebSocket 连接，写操作需要串行
+type websocketConn struct {
+	conn    *websocket.Conn
+	session *channelSession
+	writeMu sync.Mutex
+	timeout time.Duration
+}
+
+// newWebSocketServer 创建 WebSocket 传输
+func newWebSocketServer(a *OpenAPIToMCPAdapter) *websocketServer {
+	cfg := a.websocketConfig
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+	if cfg.PongTimeout <= 0 {
+		cfg.PongTimeout = 2 * cfg.PingInterval
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10 * time.Second
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = 10 << 20
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 32
+	}
+	return &websocketServer{
+		adapter:  a,
+		cfg:      cfg,
+		upgrader: websocket.Upgrader{CheckOrigin: cfg.CheckOrigin},
+		conns:    make(map[*websocketConn]struct{}),
+	}
+}
+
+// ServeHTTP 升级为 WebSocket 连接并处理消息，直到连接断开
+func (s *websocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a := s.adapter
+	if a.rejectNewSession(w) {
+		return
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		a.log().Printf("websocket upgrade: %v", err)
+		return
+	}
+
+	session := newChannelSession()
+	c := &websocketConn{conn: conn, session: session, timeout: s.cfg.WriteTimeout}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	registerCtx := ctx
+	if len(a.capturedHeaders()) > 0 {
+		registerCtx = context.WithValue(ctx, identityKey{}, a.captureIdentity(r))
+	}
+	if err := a.server.RegisterSession(registerCtx, session); err != nil {
+		cancel()
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	var handlers sync.WaitGroup
+	defer func() {
+		// 连接断开后取消该会话所有进行中的调用
+		cancel()
+		session.close()
+		handlers.Wait()
+		a.server.UnregisterSession(session.id)
+		a.forgetSession(session.id)
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	go s.pump(c)
+
+	conn.SetReadLimit(s.cfg.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(s.cfg.PongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(s.cfg.PongTimeout))
+	})
+
+	ctx = a.sseContextFunc(a.server.WithContext(ctx, session), r)
+	inFlight := make(chan struct{}, s.cfg.MaxInFlight)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				a.log().Printf("websocket session %s: %v", session.id, err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(s.cfg.PongTimeout))
+		session.touch()
+
+		// 每条消息单独处理，进行中的调用不会阻塞 notifications/cancelled 等后续消息；
+		// 同时处理的消息数受 MaxInFlight 限制，避免一个连接堆积大量 goroutine
+		inFlight <- struct{}{}
+		handlers.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				handlers.Done()
+			}()
+			s.handle(ctx, c, data)
+		}()
+	}
+}
+
+// handle 处理一条消息并写回响应
+func (s *websocketServer) handle(ctx context.Context, c *websocketConn, data []byte) {
+	messages, batch, err := parseMessages(data)
+	if err != nil {
+		c.write(createErrorResponse(nil, mcp.PARSE_ERROR, "Parse error"))
+		return
+	}
+
+	var responses []mcp.JSONRPCMessage
+	for _, message := range messages {
+		if response := s.adapter.server.HandleMessage(ctx, message); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	switch {
+	case len(responses) == 0:
+	case batch:
+		c.write(responses)
+	default:
+		c.write(responses[0])
+	}
+}
+
+// pump 推送服务端通知并定期发送 ping，直到会话结束
+func (s *websocketServer) pump(c *websocketConn) {
+	ticker := time.NewTicker(s.cfg.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case notification := <-c.session.notifications:
+			if err := c.write(notification); err != nil {
+				return
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(s.cfg.WriteTimeout)
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case <-c.session.done:
+			return
+		}
+	}
+}
+
+// write 以文本消息写入 JSON
+func (c *websocketConn) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// shutdown 向所有连接发送关闭帧
+func (s *websocketServer) shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	conns := make([]*websocketConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+		c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(s.cfg.WriteTimeout))
+		c.conn.Close()
+	}
+	return nil
+}
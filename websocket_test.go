@@ -0,0 +1,135 @@
+package gmadapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebSocketTestServer(t *testing.T, backendURL string, cfg WebSocketConfig) (*OpenAPIToMCPAdapter, *websocketServer, string, func()) {
+	adapter := newIdentityTestAdapter(t, backendURL, WithWebSocket(cfg))
+	adapter.registerTools()
+	ws := newWebSocketServer(adapter)
+	srv := httptest.NewServer(ws)
+	return adapter, ws, "ws" + strings.TrimPrefix(srv.URL, "http"), srv.Close
+}
+
+func (s *websocketServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func TestWebSocketTransport(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("from backend"))
+	}))
+	defer backend.Close()
+
+	adapter, _, url, closeServer := newWebSocketTestServer(t, backend.URL, WebSocketConfig{})
+	defer closeServer()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	roundTrip := func(message string) string {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+		_, data, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Contains(t, roundTrip(initializeMessage), `"serverInfo"`)
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+	assert.Contains(t, roundTrip(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"_whoami_get","arguments":{}}}`), "from backend")
+	assert.Contains(t, roundTrip(`[{"jsonrpc":"2.0","id":3,"method":"ping"}]`), `[{"jsonrpc":"2.0","id":3`)
+	assert.Contains(t, roundTrip(`not json`), `"code":-32700`)
+
+	// 服务端通知推送到连接
+	adapter.server.DeleteTools("_whoami_get")
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "notifications/tools/list_changed")
+}
+
+func TestWebSocketTransport_PingPong(t *testing.T) {
+	_, ws, url, closeServer := newWebSocketTestServer(t, "http://backend", WebSocketConfig{
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  100 * time.Millisecond,
+	})
+	defer closeServer()
+
+	// 持续读取的客户端会自动回复 pong，连接保持
+	var pings int32
+	alive, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer alive.Close()
+	alive.SetPingHandler(func(data string) error {
+		atomic.AddInt32(&pings, 1)
+		return alive.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// 不读取的客户端不会回复 pong，超时后被断开
+	silent, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer silent.Close()
+
+	assert.Eventually(t, func() bool { return ws.connections() == 2 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return ws.connections() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Greater(t, atomic.LoadInt32(&pings), int32(3))
+
+	ws.shutdown(context.Background())
+	assert.Eventually(t, func() bool { return ws.connections() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestWebSocketTransport_MaxInFlight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte("from backend"))
+	}))
+	defer backend.Close()
+
+	_, _, url, closeServer := newWebSocketTestServer(t, backend.URL, WebSocketConfig{MaxInFlight: 1})
+	defer closeServer()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(initializeMessage)))
+	_, _, err = conn.ReadMessage()
+	assert.NoError(t, err)
+
+	// 达到上限后第二条消息等第一条处理完才开始处理
+	for id := 2; id <= 3; id++ {
+		assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"_whoami_get","arguments":{}}}`, id))))
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		_, data, err := conn.ReadMessage()
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "from backend")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,220 @@
+package gmadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ErrWorkerQueueFull 表示等待队列已满
+var ErrWorkerQueueFull = errors.New("worker pool queue is full")
+
+// WorkerPoolConfig 是后端调用工作池的配置
+type WorkerPoolConfig struct {
+	// MaxConcurrent 全局同时进行的后端调用上限，0 表示不限制
+	MaxConcurrent int `json:"max_concurrent,omitempty" yaml:"max_concurrent,omitempty"`
+	// MaxConcurrentPerBackend 每个后端同时进行的调用上限，0 表示不限制
+	MaxConcurrentPerBackend int `json:"max_concurrent_per_backend,omitempty" yaml:"max_concurrent_per_backend,omitempty"`
+	// MaxQueue 等待队列长度上限，0 表示不限制，负数表示不排队直接拒绝
+	MaxQueue int `json:"max_queue,omitempty" yaml:"max_queue,omitempty"`
+	// QueueTimeout 在队列中等待的最长时间，0 表示只受调用上下文限制
+	QueueTimeout time.Duration `json:"queue_timeout,omitempty" yaml:"queue_timeout,omitempty"`
+	// SessionFairness 开启后同优先级的等待者中优先调度在途调用最少的会话
+	SessionFairness bool `json:"session_fairness,omitempty" yaml:"session_fairness,omitempty"`
+	// Priorities 按工具名设置优先级，数值越大越先调度，默认 0
+	Priorities map[string]int `json:"priorities,omitempty" yaml:"priorities,omitempty"`
+}
+
+// WorkerPool 限制后端调用的并发数，可在多个适配器之间共享
+type WorkerPool struct {
+	cfg WorkerPoolConfig
+
+	mu       sync.Mutex
+	inflight int
+	backends map[string]int
+	sessions map[string]int
+	queue    []*poolWaiter
+	seq      uint64
+}
+
+// poolWaiter 是队列中等待执行的调用
+type poolWaiter struct {
+	backend  string
+	session  string
+	priority int
+	seq      uint64
+	granted  bool
+	ready    chan struct{}
+}
+
+// NewWorkerPool 创建工作池
+func NewWorkerPool(cfg WorkerPoolConfig) *WorkerPool {
+	return &WorkerPool{
+		cfg:      cfg,
+		backends: make(map[string]int),
+		sessions: make(map[string]int),
+	}
+}
+
+// WithWorkerPool 让后端调用经过工作池调度
+func WithWorkerPool(pool *WorkerPool) Option {
+	return func(a *OpenAPIToMCPAdapter) {
+		a.workerPool = pool
+	}
+}
+
+// Stats 返回工作池当前的在途调用数和排队数
+func (p *WorkerPool) Stats() (inflight, queued int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inflight, len(p.queue)
+}
+
+// acquire 获取一个执行槽位，返回释放函数
+func (p *WorkerPool) acquire(ctx context.Context, backend, session, toolName string) (func(), error) {
+	p.mu.Lock()
+	p.seq++
+	w := &poolWaiter{
+		backend:  backend,
+		session:  session,
+		priority: p.cfg.Priorities[toolName],
+		seq:      p.seq,
+		ready:    make(chan struct{}),
+	}
+	p.queue = append(p.queue, w)
+	p.dispatch()
+	if !w.granted && (p.cfg.MaxQueue < 0 || (p.cfg.MaxQueue > 0 && len(p.queue) > p.cfg.MaxQueue)) {
+		p.remove(w)
+		p.mu.Unlock()
+		return nil, ErrWorkerQueueFull
+	}
+	p.mu.Unlock()
+
+	release := func() { p.release(w) }
+	if p.cfg.QueueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.QueueTimeout)
+		defer cancel()
+	}
+
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		granted := w.granted
+		if !granted {
+			p.remove(w)
+		}
+		p.mu.Unlock()
+		if granted {
+			return release, nil
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// release 归还槽位并调度下一个等待者
+func (p *WorkerPool) release(w *poolWaiter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inflight--
+	p.backends[w.backend]--
+	if p.backends[w.backend] <= 0 {
+		delete(p.backends, w.backend)
+	}
+	p.sessions[w.session]--
+	if p.sessions[w.session] <= 0 {
+		delete(p.sessions, w.session)
+	}
+	p.dispatch()
+}
+
+// dispatch 在有空闲槽位时按优先级、会话公平性和到达顺序放行等待者，调用方需持有锁
+func (p *WorkerPool) dispatch() {
+	for p.cfg.MaxConcurrent <= 0 || p.inflight < p.cfg.MaxConcurrent {
+		var best *poolWaiter
+		for _, w := range p.queue {
+			if p.cfg.MaxConcurrentPerBackend > 0 && p.backends[w.backend] >= p.cfg.MaxConcurrentPerBackend {
+				continue
+			}
+			if best == nil || p.before(w, best) {
+				best = w
+			}
+		}
+		if best == nil {
+			return
+		}
+
+		p.remove(best)
+		best.granted = true
+		p.inflight++
+		p.backends[best.backend]++
+		p.sessions[best.session]++
+		close(best.ready)
+	}
+}
+
+// before 判断 w 是否应排在 other 之前
+func (p *WorkerPool) before(w, other *poolWaiter) bool {
+	if w.priority != other.priority {
+		return w.priority > other.priority
+	}
+	if p.cfg.SessionFairness && w.session != other.session {
+		if a, b := p.sessions[w.session], p.sessions[other.session]; a != b {
+			return a < b
+		}
+	}
+	return w.seq < other.seq
+}
+
+// remove 把等待者移出队列，调用方需持有锁
+func (p *WorkerPool) remove(w *poolWaiter) {
+	for i, q := range p.queue {
+		if q == w {
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// acquireWorker 为工具调用获取工作池槽位，无法获取时返回说明原因的错误结果
+func (a *OpenAPIToMCPAdapter) acquireWorker(ctx context.Context, op toolOperation) (func(), *mcp.CallToolResult, error) {
+	if a.workerPool == nil {
+		return func() {}, nil, nil
+	}
+
+	session := ""
+	if s := server.ClientSessionFromContext(ctx); s != nil {
+		session = s.SessionID()
+	}
+
+	start := time.Now()
+	release, err := a.workerPool.acquire(ctx, a.backendBaseUrl, session, op.toolName)
+	a.metrics.observe("mcp_adapter_worker_queue_wait", time.Since(start), "tool", op.toolName)
+	switch {
+	case err == nil:
+		return release, nil, nil
+	case errors.Is(err, ErrWorkerQueueFull):
+		a.metrics.add("mcp_adapter_worker_rejections_total", 1, "tool", op.toolName, "reason", "queue_full")
+		_, queued := a.workerPool.Stats()
+		return nil, mcp.NewToolResultError(fmt.Sprintf("too many concurrent backend calls (%d waiting); try again later", queued)), nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		a.metrics.add("mcp_adapter_worker_rejections_total", 1, "tool", op.toolName, "reason", "queue_timeout")
+		return nil, mcp.NewToolResultError(fmt.Sprintf("timed out after %s waiting for a free backend worker", a.workerPool.cfg.QueueTimeout)), nil
+	default:
+		return nil, nil, err
+	}
+}
+
+// workerPoolGauge 在指标中输出工作池的在途调用数和排队数
+func (a *OpenAPIToMCPAdapter) workerPoolGauge(emit func(name string, value float64, labels ...string)) {
+	inflight, queued := a.workerPool.Stats()
+	emit("mcp_adapter_worker_inflight", float64(inflight))
+	emit("mcp_adapter_worker_queue_depth", float64(queued))
+}
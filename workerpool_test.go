@@ -0,0 +1,123 @@
+package gmadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_BoundsConcurrency(t *testing.T) {
+	var current, peak int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithWorkerPool(NewWorkerPool(WorkerPoolConfig{MaxConcurrent: 2})))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+			assert.NoError(t, err)
+			assert.False(t, result.IsError)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+	assert.Equal(t, 6.0, adapter.Metrics()[`mcp_adapter_worker_queue_wait_seconds_count{tool="_whoami_get"}`])
+}
+
+func TestWorkerPool_RejectsWhenQueueFull(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-unblock
+	}))
+	defer backend.Close()
+
+	adapter := newIdentityTestAdapter(t, backend.URL, WithWorkerPool(NewWorkerPool(WorkerPoolConfig{MaxConcurrent: 1, MaxQueue: -1})))
+
+	done := make(chan struct{})
+	go func() {
+		adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+		close(done)
+	}()
+	<-started
+
+	result, err := adapter.handlers["_whoami_get"](context.Background(), mcp.CallToolRequest{})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	close(unblock)
+	<-done
+}
+
+func TestWorkerPool_PriorityAndPerBackend(t *testing.T) {
+	pool := NewWorkerPool(WorkerPoolConfig{
+		MaxConcurrent:           2,
+		MaxConcurrentPerBackend: 1,
+		Priorities:              map[string]int{"urgent": 10},
+	})
+	ctx := context.Background()
+
+	release, err := pool.acquire(ctx, "a", "s1", "normal")
+	assert.NoError(t, err)
+
+	order := make(chan string, 3)
+	var wg sync.WaitGroup
+	enqueue := func(backend, tool string, depth int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := pool.acquire(ctx, backend, "s1", tool)
+			assert.NoError(t, err)
+			order <- backend + ":" + tool
+			r()
+		}()
+		for {
+			if _, queued := pool.Stats(); queued == depth {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	enqueue("a", "normal", 1)
+	enqueue("a", "urgent", 2)
+
+	// 后端 b 不受后端 a 的并发上限影响
+	rb, err := pool.acquire(ctx, "b", "s2", "normal")
+	assert.NoError(t, err)
+	rb()
+
+	release()
+	wg.Wait()
+	close(order)
+
+	var got []string
+	for o := range order {
+		got = append(got, o)
+	}
+	assert.Equal(t, []string{"a:urgent", "a:normal"}, got)
+}